@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterIgnitionV3Spec defines the desired state of ClusterIgnitionV3.
+// +kubebuilder:validation:XValidation:rule="!has(self.storage) || !has(self.storage.files) || self.storage.files.all(f, !has(f.contentsFrom))", message="cluster ignitions can't use contentsFrom"
+// +kubebuilder:validation:XValidation:rule="!has(self.passwd) || !has(self.passwd.users) || self.passwd.users.all(u, !has(u.passwordHashFrom) && !has(u.sshAuthorizedKeysFrom))", message="cluster ignitions can't use passwordHashFrom and sshAuthorizedKeysFrom"
+// +kubebuilder:validation:XValidation:rule="!has(self.ignition.config) || (!has(self.ignition.config.merge) && !has(self.ignition.config.replace) && !has(self.ignition.config.mergeRefs) && !has(self.ignition.config.mergeFrom) && !has(self.ignition.config.namespaceSelector))", message="cluster ignitions can only merge other cluster ignitions using clusterMerge"
+type ClusterIgnitionV3Spec struct {
+	// Priority defines the order in which cluster ignitions selected by clusterMerge are merged.
+	// Cluster ignitions with a higher priority are merged later and take precedence, ones with equal priority are ordered by name.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	Config `json:",inline"`
+}
+
+// ClusterIgnitionV3Status defines the observed state of ClusterIgnitionV3.
+type ClusterIgnitionV3Status struct {
+	// TargetIgnitions is a list of Ignitions with TargetSecret that merged this cluster ignition
+	TargetIgnitions []IgnitionReference `json:"targetIgnitions,omitempty"`
+}
+
+// IgnitionReference references an IgnitionV3 in an arbitrary namespace.
+type IgnitionReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=cign
+
+// ClusterIgnitionV3 is the Schema for the clusterignitionv3s API.
+type ClusterIgnitionV3 struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterIgnitionV3Spec   `json:"spec,omitempty"`
+	Status ClusterIgnitionV3Status `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterIgnitionV3List contains a list of ClusterIgnitionV3.
+type ClusterIgnitionV3List struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterIgnitionV3 `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterIgnitionV3{}, &ClusterIgnitionV3List{})
+}
@@ -35,11 +35,18 @@ type Config struct {
 	Systemd         Systemd         `json:"systemd,omitempty"`
 }
 
+// ConfigSource selects a key of a Secret or a ConfigMap.
+// +kubebuilder:validation:XValidation:rule="has(self.secretKeyRef) != has(self.configMapKeyRef)", message="exactly one of secretKeyRef and configMapKeyRef has to be set"
+type ConfigSource struct {
+	SecretKeyRef    *v1.SecretKeySelector    `json:"secretKeyRef,omitempty"`
+	ConfigMapKeyRef *v1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
 type Device string
 
 type Directory struct {
-	Node               `json:"node,omitempty"`
-	DirectoryEmbedded1 `json:"directoryEmbedded1,omitempty"`
+	Node               `json:",inline"`
+	DirectoryEmbedded1 `json:",inline"`
 }
 
 type DirectoryEmbedded1 struct {
@@ -58,14 +65,26 @@ type Dropin struct {
 }
 
 type File struct {
-	Node          `json:"node,omitempty"`
-	FileEmbedded1 `json:"fileEmbedded1,omitempty"`
+	Node          `json:",inline"`
+	FileEmbedded1 `json:",inline"`
 }
 
+// +kubebuilder:validation:XValidation:rule="!has(self.contentsFrom) || !has(self.contents) || !has(self.contents.source)", message="contents.source and contentsFrom are mutually exclusive"
 type FileEmbedded1 struct {
 	Append   []Resource `json:"append,omitempty"`
 	Contents Resource   `json:"contents,omitempty"`
-	Mode     *int       `json:"mode,omitempty"`
+	// ContentsFrom selects a key of a Secret or a ConfigMap which is inlined as data URL into contents when the config is rendered.
+	ContentsFrom *FileContentsSource `json:"contentsFrom,omitempty"`
+	Mode         *int                `json:"mode,omitempty"`
+}
+
+// FileContentsSource selects a key of a Secret or a ConfigMap holding the contents of a file.
+type FileContentsSource struct {
+	ConfigSource `json:",inline"`
+	// Compression compresses the contents before they are inlined.
+	// +kubebuilder:validation:Enum=gzip
+	// +optional
+	Compression *string `json:"compression,omitempty"`
 }
 
 type Filesystem struct {
@@ -99,8 +118,20 @@ type Ignition struct {
 }
 
 type IgnitionConfig struct {
-	Merge   *metav1.LabelSelector    `json:"merge,omitempty"`
-	Replace *v1.LocalObjectReference `json:"replace,omitempty"`
+	Merge *metav1.LabelSelector `json:"merge,omitempty"`
+	// NamespaceSelector selects further namespaces in which Merge looks for ignitions.
+	// A namespace has to grant access with an IgnitionV3Grant before its ignitions can be merged.
+	NamespaceSelector *metav1.LabelSelector    `json:"namespaceSelector,omitempty"`
+	Replace           *v1.LocalObjectReference `json:"replace,omitempty"`
+	// MergeRefs is an ordered list of ignitions merged after the ones selected by Merge.
+	// The TargetSecret isn't updated as long as one of them doesn't exist.
+	MergeRefs []v1.LocalObjectReference `json:"mergeRefs,omitempty"`
+	// ClusterMerge selects ClusterIgnitionV3 objects to merge. They are merged before the ones selected by Merge.
+	ClusterMerge *metav1.LabelSelector `json:"clusterMerge,omitempty"`
+	// MergeFrom is an ordered list of Secrets and ConfigMaps containing ignition configs which are merged
+	// after the ignitions referenced by MergeRefs.
+	// The TargetSecret isn't updated as long as one of them doesn't exist, unless it is marked as optional.
+	MergeFrom []ConfigSource `json:"mergeFrom,omitempty"`
 }
 
 type KernelArgument string
@@ -111,8 +142,8 @@ type KernelArguments struct {
 }
 
 type Link struct {
-	Node          `json:"node,omitempty"`
-	LinkEmbedded1 `json:"linkEmbedded1,omitempty"`
+	Node          `json:",inline"`
+	LinkEmbedded1 `json:",inline"`
 }
 
 type LinkEmbedded1 struct {
@@ -184,21 +215,27 @@ type PasswdGroup struct {
 	System       *bool   `json:"system,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:rule="!has(self.passwordHash) || !has(self.passwordHashFrom)", message="passwordHash and passwordHashFrom are mutually exclusive"
 type PasswdUser struct {
-	Gecos             *string            `json:"gecos,omitempty"`
-	Groups            []Group            `json:"groups,omitempty"`
-	HomeDir           *string            `json:"homeDir,omitempty"`
-	Name              string             `json:"name"`
-	NoCreateHome      *bool              `json:"noCreateHome,omitempty"`
-	NoLogInit         *bool              `json:"noLogInit,omitempty"`
-	NoUserGroup       *bool              `json:"noUserGroup,omitempty"`
-	PasswordHash      *string            `json:"passwordHash,omitempty"`
-	PrimaryGroup      *string            `json:"primaryGroup,omitempty"`
-	SSHAuthorizedKeys []SSHAuthorizedKey `json:"sshAuthorizedKeys,omitempty"`
-	Shell             *string            `json:"shell,omitempty"`
-	ShouldExist       *bool              `json:"shouldExist,omitempty"`
-	System            *bool              `json:"system,omitempty"`
-	UID               *int               `json:"uid,omitempty"`
+	Gecos        *string `json:"gecos,omitempty"`
+	Groups       []Group `json:"groups,omitempty"`
+	HomeDir      *string `json:"homeDir,omitempty"`
+	Name         string  `json:"name"`
+	NoCreateHome *bool   `json:"noCreateHome,omitempty"`
+	NoLogInit    *bool   `json:"noLogInit,omitempty"`
+	NoUserGroup  *bool   `json:"noUserGroup,omitempty"`
+	PasswordHash *string `json:"passwordHash,omitempty"`
+	// PasswordHashFrom selects a key of a Secret holding the password hash, which is resolved when the config is rendered.
+	PasswordHashFrom  *v1.SecretKeySelector `json:"passwordHashFrom,omitempty"`
+	PrimaryGroup      *string               `json:"primaryGroup,omitempty"`
+	SSHAuthorizedKeys []SSHAuthorizedKey    `json:"sshAuthorizedKeys,omitempty"`
+	// SSHAuthorizedKeysFrom selects keys of Secrets holding SSH authorized keys, one per line,
+	// which are appended to sshAuthorizedKeys when the config is rendered.
+	SSHAuthorizedKeysFrom []v1.SecretKeySelector `json:"sshAuthorizedKeysFrom,omitempty"`
+	Shell                 *string                `json:"shell,omitempty"`
+	ShouldExist           *bool                  `json:"shouldExist,omitempty"`
+	System                *bool                  `json:"system,omitempty"`
+	UID                   *int                   `json:"uid,omitempty"`
 }
 
 type Proxy struct {
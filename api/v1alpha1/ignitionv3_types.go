@@ -6,17 +6,184 @@ package v1alpha1
 import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // IgnitionV3Spec defines the desired state of IgnitionV3.
 // +kubebuilder:validation:XValidation:rule="!has(oldSelf.targetSecret) || has(self.targetSecret)", message="targetSecret is required once set"
+// +kubebuilder:validation:XValidation:rule="has(self.targetSecret) || (!has(self.variables) && !has(self.variablesFrom))", message="variables and variablesFrom require targetSecret"
+// +kubebuilder:validation:XValidation:rule="has(self.targetSecret) || !has(self.tokenFrom)", message="tokenFrom requires targetSecret"
+// +kubebuilder:validation:XValidation:rule="!has(self.pointer) || has(self.tokenFrom)", message="pointer requires tokenFrom"
+// +kubebuilder:validation:XValidation:rule="has(self.targetSecret) || !has(self.outputs)", message="outputs require targetSecret"
+// +kubebuilder:validation:XValidation:rule="has(self.targetSecret) || !has(self.conflictPolicy)", message="conflictPolicy requires targetSecret"
+// +kubebuilder:validation:XValidation:rule="has(self.targetSecret) || !has(self.mergeStrategy)", message="mergeStrategy requires targetSecret"
+// +kubebuilder:validation:XValidation:rule="!has(self.targetSecret) || !has(self.outputs) || self.outputs.all(o, !has(o.secret) || (o.secret.name != self.targetSecret.name && self.outputs.exists_one(p, has(p.secret) && p.secret.name == o.secret.name)))", message="the secrets of outputs have to differ from each other and from targetSecret"
+// +kubebuilder:validation:XValidation:rule="!has(self.outputs) || self.outputs.all(o, !has(o.configMap) || self.outputs.exists_one(p, has(p.configMap) && p.configMap.name == o.configMap.name))", message="the config maps of outputs have to differ from each other"
 type IgnitionV3Spec struct {
-	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="targetSecret is immutable"
-	TargetSecret *v1.LocalObjectReference `json:"targetSecret,omitempty"`
+	// TargetSecret is the Secret the merged configuration is written to. Ignitions with a TargetSecret are targets,
+	// the ignitions they merge are only rendered into them.
+	// +kubebuilder:validation:XValidation:rule="self.name == oldSelf.name && self.type == oldSelf.type",message="name and type of targetSecret are immutable"
+	TargetSecret *TargetSecret `json:"targetSecret,omitempty"`
+
+	// OutputVersion is the ignition specification version the merged configuration is rendered in.
+	// The newest supported version is used when empty.
+	// +kubebuilder:validation:Enum="3.0.0";"3.1.0";"3.2.0";"3.3.0";"3.4.0";"3.5.0"
+	// +optional
+	OutputVersion string `json:"outputVersion,omitempty"`
+
+	// Priority defines the order in which ignitions selected by merge are merged.
+	// Ignitions with a higher priority are merged later and take precedence, ignitions with equal priority are ordered by name.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// Butane is a Butane config which is translated to ignition and merged on top of the ignition config of this spec.
+	// Local files and trees aren't supported, as there is no files directory to resolve them against.
+	// +optional
+	Butane string `json:"butane,omitempty"`
+
+	// Raw is an ignition config which is merged on top of the ignition config of this spec before Butane.
+	// It isn't validated by the API server and allows using ignition fields which aren't part of this API yet.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Raw *runtime.RawExtension `json:"raw,omitempty"`
+
+	// Variables are substituted for ${name} placeholders in the file contents, systemd units and kernel arguments
+	// of the merged configuration. Placeholders are only substituted when variables or variablesFrom are set,
+	// $${name} is always rendered as literal ${name}. Variables take precedence over the ones of variablesFrom.
+	// +optional
+	Variables map[string]string `json:"variables,omitempty"`
+
+	// VariablesFrom lists ConfigMaps whose keys are used as variables. Later ConfigMaps take precedence.
+	// +optional
+	VariablesFrom []v1.LocalObjectReference `json:"variablesFrom,omitempty"`
+
+	// TokenFrom selects a key of a Secret holding the bearer token machines use to fetch the merged configuration
+	// from the ignition HTTP server. The configuration is only served when it is set.
+	// +optional
+	TokenFrom *v1.SecretKeySelector `json:"tokenFrom,omitempty"`
+
+	// Pointer replaces the merged configuration in the TargetSecret with a pointer config, which fetches the merged
+	// configuration from the ignition HTTP server and verifies its hash. The merged configuration is written
+	// to a Secret named after the ignition with the suffix -rendered instead.
+	// +optional
+	Pointer *IgnitionV3Pointer `json:"pointer,omitempty"`
+
+	// Outputs are further Secrets and ConfigMaps the merged configuration is written to, each in its own
+	// ignition specification version. They always contain the merged configuration, not a pointer config.
+	// The objects of outputs removed from the spec are deleted.
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=8
+	// +optional
+	Outputs []IgnitionV3Output `json:"outputs,omitempty"`
+
+	// ConflictPolicy defines how files, units, dropins, users, groups and filesystems defined with differing content
+	// by several merged objects, and kernel arguments required by one merged object and forbidden by another, are
+	// handled. Fail doesn't update the TargetSecret, Warn merges them and reports them in the Conflicts condition,
+	// FirstWins and LastWins keep the definition of the object merged first or last.
+	// Only the policy of the target ignition is used, it applies to all nested merges. Warn is used when empty.
+	// +kubebuilder:validation:Enum=Fail;Warn;FirstWins;LastWins
+	// +optional
+	ConflictPolicy string `json:"conflictPolicy,omitempty"`
+
+	// MergeStrategy defines how merged objects extending the same unit or file are combined. The dropins of a unit
+	// are always collected by their name and the appends of a file in merge order from all merged objects. Aggregate
+	// additionally drops appends repeating an earlier append of the same file, and keeps the dropins of a unit losing
+	// a conflict under FirstWins or LastWins. Merge, which is used when empty, drops them along with the losing unit.
+	// Only the strategy of the target ignition is used, it applies to all nested merges.
+	// +kubebuilder:validation:Enum=Merge;Aggregate
+	// +optional
+	MergeStrategy string `json:"mergeStrategy,omitempty"`
 
 	Config `json:",inline"`
 }
 
+// TargetSecret describes the Secret the merged configuration of a target ignition is written to.
+// The data of the Secret is owned by the ignition, keys which aren't part of this spec are removed.
+// +kubebuilder:validation:XValidation:rule="!has(self.extraData) || !(self.key in self.extraData)", message="extraData must not contain key"
+type TargetSecret struct {
+	// Name is the name of the Secret.
+	// +kubebuilder:validation:MaxLength=253
+	Name string `json:"name"`
+
+	// Key is the key the merged configuration is written to, e.g. userData or value.
+	// +kubebuilder:default=config
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// Type is the type of the Secret.
+	// +kubebuilder:default=Opaque
+	// +optional
+	Type v1.SecretType `json:"type,omitempty"`
+
+	// ExtraData are static keys written to the Secret along with the merged configuration, e.g. a format key.
+	// +optional
+	ExtraData map[string]string `json:"extraData,omitempty"`
+
+	// Labels are added to the Secret.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are added to the Secret.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// TargetConfigMap describes a ConfigMap the merged configuration of a target ignition is written to.
+// The data of the ConfigMap is owned by the ignition, keys which aren't part of this spec are removed.
+// +kubebuilder:validation:XValidation:rule="!has(self.extraData) || !(self.key in self.extraData)", message="extraData must not contain key"
+type TargetConfigMap struct {
+	// Name is the name of the ConfigMap.
+	// +kubebuilder:validation:MaxLength=253
+	Name string `json:"name"`
+
+	// Key is the key the merged configuration is written to.
+	// +kubebuilder:default=config
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// ExtraData are static keys written to the ConfigMap along with the merged configuration, e.g. a format key.
+	// +optional
+	ExtraData map[string]string `json:"extraData,omitempty"`
+
+	// Labels are added to the ConfigMap.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are added to the ConfigMap.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// IgnitionV3Output describes a further object the merged configuration of a target ignition is written to.
+// +kubebuilder:validation:XValidation:rule="has(self.secret) != has(self.configMap)", message="exactly one of secret and configMap has to be set"
+type IgnitionV3Output struct {
+	// Name identifies the output in the status.
+	Name string `json:"name"`
+
+	// Secret is the Secret the merged configuration is written to.
+	// +optional
+	Secret *TargetSecret `json:"secret,omitempty"`
+
+	// ConfigMap is the ConfigMap the merged configuration is written to. It isn't written when the merged
+	// configuration contains data of Secrets, e.g. from contentsFrom, passwordHashFrom or mergeFrom.
+	// +optional
+	ConfigMap *TargetConfigMap `json:"configMap,omitempty"`
+
+	// OutputVersion is the ignition specification version the merged configuration is rendered in.
+	// The outputVersion of the spec is used when empty.
+	// +kubebuilder:validation:Enum="3.0.0";"3.1.0";"3.2.0";"3.3.0";"3.4.0";"3.5.0"
+	// +optional
+	OutputVersion string `json:"outputVersion,omitempty"`
+}
+
+// IgnitionV3Pointer configures the pointer config written to a TargetSecret.
+type IgnitionV3Pointer struct {
+	// URL is the base URL machines reach the ignition HTTP server at, e.g. http://khalkeon.example.com:8080.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+}
+
 // IgnitionV3Status defines the observed state of IgnitionV3.
 type IgnitionV3Status struct {
 	// Conditions represents the latest available observations of the ignition's current state.
@@ -26,18 +193,127 @@ type IgnitionV3Status struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
 
 	// TargetIgnitions is a list of Ignitions with TargetSecret that merged this ignition
-	TargetIgnitions []v1.LocalObjectReference `json:"targetIgnitions,omitempty"`
+	TargetIgnitions []IgnitionReference `json:"targetIgnitions,omitempty"`
 	// TODO what if merge is changed and Ignition is no longer used for a secret. It will trigger unnecessary reconciliation.
+
+	// MergedIgnitions lists the ignitions merged into the TargetSecret in the order they were merged.
+	// Later ignitions take precedence over earlier ones.
+	MergedIgnitions []MergedIgnition `json:"mergedIgnitions,omitempty"`
+
+	// RenderedSize is the size in bytes of the rendered merged configuration.
+	RenderedSize int64 `json:"renderedSize,omitempty"`
+
+	// OutputStrategy is how the merged configuration is stored in its Secret. Plain stores it as is, Compressed stores
+	// a wrapper config replacing itself with the gzipped merged configuration, as it exceeds the Secret size limit otherwise.
+	// +kubebuilder:validation:Enum=Plain;Compressed
+	OutputStrategy string `json:"outputStrategy,omitempty"`
+
+	// Outputs describes the state of the outputs of the spec.
+	// +listType=map
+	// +listMapKey=name
+	Outputs []OutputStatus `json:"outputs,omitempty"`
+}
+
+// OutputStatus describes the state of an output.
+type OutputStatus struct {
+	// Name is the name of the output.
+	Name string `json:"name"`
+
+	// Ready is true when the merged configuration is written to the object of the output.
+	Ready bool `json:"ready"`
+
+	// Message describes why the output isn't ready.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// RenderedSize is the size in bytes of the merged configuration rendered for the output.
+	// +optional
+	RenderedSize int64 `json:"renderedSize,omitempty"`
+
+	// OutputStrategy is how the merged configuration is stored in the object of the output.
+	// +kubebuilder:validation:Enum=Plain;Compressed
+	// +optional
+	OutputStrategy string `json:"outputStrategy,omitempty"`
+}
+
+// MergedIgnition references an ignition which was merged into a TargetSecret.
+type MergedIgnition struct {
+	// Kind is IgnitionV3, ClusterIgnitionV3, or Secret and ConfigMap for configs merged with mergeFrom.
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Priority  int32  `json:"priority,omitempty"`
+	// Version is the ignition specification version the ignition was written for.
+	// It is translated to the newest supported version before merging.
+	Version string `json:"version,omitempty"`
+	// Keys is the number of files, directories, links, storage devices, units, dropins, users, groups and
+	// kernel arguments of the merged configuration set by the ignition. The provenance ConfigMap lists them.
+	Keys int32 `json:"keys,omitempty"`
 }
 
 const (
 	ConfigurationType = "Configuration"
 	SecretType        = "Secret"
+	ReferencesType    = "References"
+	ConflictsType     = "Conflicts"
+)
+
+const (
+	ConflictPolicyFail      = "Fail"
+	ConflictPolicyWarn      = "Warn"
+	ConflictPolicyFirstWins = "FirstWins"
+	ConflictPolicyLastWins  = "LastWins"
+)
+
+const (
+	MergeStrategyMerge     = "Merge"
+	MergeStrategyAggregate = "Aggregate"
+)
+
+const (
+	OutputStrategyPlain      = "Plain"
+	OutputStrategyCompressed = "Compressed"
+)
+
+// TargetSecretConfigKey is the default key of a TargetSecret containing the merged configuration.
+const TargetSecretConfigKey = "config"
+
+// RenderedSecretSuffix is appended to the name of a target ignition using a pointer config to name the Secret
+// holding its merged configuration.
+const RenderedSecretSuffix = "-rendered"
+
+// ProvenanceConfigMapSuffix is appended to the name of a target ignition to name the ConfigMap recording
+// which merged ignition set each file, unit, user and kernel argument of its merged configuration.
+const ProvenanceConfigMapSuffix = "-provenance"
+
+// ProvenanceConfigMapKey is the key of the provenance ConfigMap containing the provenance as JSON.
+const ProvenanceConfigMapKey = "provenance.json"
+
+// ProvenanceAnnotation is set on the TargetSecret to the name of the provenance ConfigMap, unless the ConfigMap
+// exists without being owned by the ignition.
+const ProvenanceAnnotation = "metal.cobaltcore.dev/provenance"
+
+const (
+	// ManagedDataAnnotation lists the data keys written to a Secret or ConfigMap from the spec, so they are removed
+	// once they are removed from the spec while keys written by others are kept.
+	ManagedDataAnnotation = "metal.cobaltcore.dev/managed-data"
+	// ManagedLabelsAnnotation lists the labels set on an object from the spec.
+	ManagedLabelsAnnotation = "metal.cobaltcore.dev/managed-labels"
+	// ManagedAnnotationsAnnotation lists the annotations set on an object from the spec.
+	ManagedAnnotationsAnnotation = "metal.cobaltcore.dev/managed-annotations"
+)
+
+const (
+	IgnitionV3Kind        = "IgnitionV3"
+	ClusterIgnitionV3Kind = "ClusterIgnitionV3"
+	SecretKind            = "Secret"
+	ConfigMapKind         = "ConfigMap"
 )
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Namespaced,shortName=ign
+// +kubebuilder:validation:XValidation:rule="!has(self.spec.outputs) || self.spec.outputs.all(o, (!has(o.secret) || o.secret.name != self.metadata.name + '-rendered') && (!has(o.configMap) || o.configMap.name != self.metadata.name + '-provenance'))", message="outputs must not use the names of the rendered secret and the provenance config map"
 
 // IgnitionV3 is the Schema for the ignitionv3s API.
 type IgnitionV3 struct {
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IgnitionV3GrantSpec defines which namespaces may merge the IgnitionV3 objects of the grant's namespace.
+type IgnitionV3GrantSpec struct {
+	// From is a list of namespaces whose IgnitionV3 objects are allowed to merge IgnitionV3 objects of this namespace.
+	// +kubebuilder:validation:MinItems=1
+	From []IgnitionV3GrantFrom `json:"from"`
+}
+
+// IgnitionV3GrantFrom describes a namespace which is granted access.
+type IgnitionV3GrantFrom struct {
+	Namespace string `json:"namespace"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced,shortName=igngrant
+
+// IgnitionV3Grant is the Schema for the ignitionv3grants API.
+// It allows IgnitionV3 objects of other namespaces to merge IgnitionV3 objects of its namespace
+// using namespaceSelector.
+type IgnitionV3Grant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec IgnitionV3GrantSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// IgnitionV3GrantList contains a list of IgnitionV3Grant.
+type IgnitionV3GrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IgnitionV3Grant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IgnitionV3Grant{}, &IgnitionV3GrantList{})
+}
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IgnitionV3SetSpec defines the desired state of IgnitionV3Set.
+// +kubebuilder:validation:XValidation:rule="has(self.instances) || has(self.instancesFrom)", message="at least one of instances and instancesFrom has to be set"
+type IgnitionV3SetSpec struct {
+	// Template is the IgnitionV3 generated for every instance.
+	Template IgnitionV3Template `json:"template"`
+
+	// Instances lists the instances a target IgnitionV3 is generated for.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Instances []IgnitionV3SetInstance `json:"instances,omitempty"`
+
+	// InstancesFrom selects a ConfigMap whose keys are the names of further instances.
+	// The value of each key is a YAML map of the variables of that instance.
+	// +optional
+	InstancesFrom *v1.LocalObjectReference `json:"instancesFrom,omitempty"`
+}
+
+// IgnitionV3Template describes the IgnitionV3 generated for an instance of an IgnitionV3Set.
+// +kubebuilder:validation:XValidation:rule="has(self.spec.targetSecret) && self.spec.targetSecret.name.contains('${instance}')", message="the name of targetSecret has to contain ${instance}"
+type IgnitionV3Template struct {
+	// Labels are added to the generated IgnitionV3 objects and removed from them once they are removed here.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Spec is the spec of the generated IgnitionV3 objects. ${instance} in the names of targetSecret and of the
+	// objects of outputs is replaced with the instance name. The variables of the instance take precedence over
+	// the variables of the spec, the variable instance is always set to the instance name.
+	Spec IgnitionV3Spec `json:"spec"`
+}
+
+// IgnitionV3SetInstance describes an instance of an IgnitionV3Set.
+type IgnitionV3SetInstance struct {
+	// Name identifies the instance. It is part of the names of the generated IgnitionV3 and its target secret.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Variables are the variables of the instance.
+	// +optional
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// IgnitionV3SetStatus defines the observed state of IgnitionV3Set.
+type IgnitionV3SetStatus struct {
+	// Conditions represents the latest available observations of the set's current state.
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// Instances is the number of IgnitionV3 objects generated by the set.
+	// +optional
+	Instances int32 `json:"instances,omitempty"`
+}
+
+const (
+	InstancesType = "Instances"
+)
+
+const (
+	// IgnitionV3SetLabel is set on generated IgnitionV3 objects to the name of their IgnitionV3Set.
+	IgnitionV3SetLabel = "metal.cobaltcore.dev/ignitionv3set"
+	// IgnitionV3SetInstanceLabel is set on generated IgnitionV3 objects to the name of their instance.
+	IgnitionV3SetInstanceLabel = "metal.cobaltcore.dev/instance"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,shortName=ignset
+
+// IgnitionV3Set is the Schema for the ignitionv3sets API.
+// It generates one target IgnitionV3 per instance from a template and deletes the ones of removed instances.
+type IgnitionV3Set struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   IgnitionV3SetSpec   `json:"spec,omitempty"`
+	Status IgnitionV3SetStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// IgnitionV3SetList contains a list of IgnitionV3Set.
+type IgnitionV3SetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IgnitionV3Set `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IgnitionV3Set{}, &IgnitionV3SetList{})
+}
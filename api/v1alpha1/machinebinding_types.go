@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MachineBindingSpec defines which machines are served the configuration of a target IgnitionV3.
+type MachineBindingSpec struct {
+	// Selector matches the attributes machines send as query parameters to the ignition HTTP server.
+	Selector MachineSelector `json:"selector"`
+
+	// Target is the IgnitionV3 of the binding's namespace whose merged configuration is served to matching machines.
+	Target v1.LocalObjectReference `json:"target"`
+}
+
+// MachineSelector selects machines by their attributes. All attributes which are set have to match.
+// +kubebuilder:validation:XValidation:rule="has(self.mac) || has(self.uuid) || has(self.serial)", message="at least one of mac, uuid and serial has to be set"
+type MachineSelector struct {
+	// MAC is the MAC address of a network interface of the machine, compared case-insensitively.
+	// +kubebuilder:validation:Pattern=`^([0-9A-Fa-f]{2}[:-]){5}[0-9A-Fa-f]{2}$`
+	// +optional
+	MAC string `json:"mac,omitempty"`
+
+	// UUID is the system UUID of the machine, compared case-insensitively.
+	// +optional
+	UUID string `json:"uuid,omitempty"`
+
+	// Serial is the serial number of the machine.
+	// +optional
+	Serial string `json:"serial,omitempty"`
+}
+
+const (
+	MachineBindingKind = "MachineBinding"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced,shortName=machbind
+
+// MachineBinding is the Schema for the machinebindings API.
+// It binds machines matching its selector to a target IgnitionV3, so the ignition HTTP server can serve
+// the target's configuration to them without per-machine URLs. Only bindings of the namespace a machine
+// requests are matched, so bindings of one namespace can't capture machines of another. When several bindings
+// match a machine, the one with the most attributes set wins.
+type MachineBinding struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec MachineBindingSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MachineBindingList contains a list of MachineBinding.
+type MachineBindingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MachineBinding `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MachineBinding{}, &MachineBindingList{})
+}
@@ -8,9 +8,9 @@
 package v1alpha1
 
 import (
-	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -96,6 +96,101 @@ func (in *ClevisCustom) DeepCopy() *ClevisCustom {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterIgnitionV3) DeepCopyInto(out *ClusterIgnitionV3) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterIgnitionV3.
+func (in *ClusterIgnitionV3) DeepCopy() *ClusterIgnitionV3 {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterIgnitionV3)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterIgnitionV3) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterIgnitionV3List) DeepCopyInto(out *ClusterIgnitionV3List) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterIgnitionV3, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterIgnitionV3List.
+func (in *ClusterIgnitionV3List) DeepCopy() *ClusterIgnitionV3List {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterIgnitionV3List)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterIgnitionV3List) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterIgnitionV3Spec) DeepCopyInto(out *ClusterIgnitionV3Spec) {
+	*out = *in
+	in.Config.DeepCopyInto(&out.Config)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterIgnitionV3Spec.
+func (in *ClusterIgnitionV3Spec) DeepCopy() *ClusterIgnitionV3Spec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterIgnitionV3Spec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterIgnitionV3Status) DeepCopyInto(out *ClusterIgnitionV3Status) {
+	*out = *in
+	if in.TargetIgnitions != nil {
+		in, out := &in.TargetIgnitions, &out.TargetIgnitions
+		*out = make([]IgnitionReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterIgnitionV3Status.
+func (in *ClusterIgnitionV3Status) DeepCopy() *ClusterIgnitionV3Status {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterIgnitionV3Status)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Config) DeepCopyInto(out *Config) {
 	*out = *in
@@ -116,6 +211,31 @@ func (in *Config) DeepCopy() *Config {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigSource) DeepCopyInto(out *ConfigSource) {
+	*out = *in
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigSource.
+func (in *ConfigSource) DeepCopy() *ConfigSource {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Directory) DeepCopyInto(out *Directory) {
 	*out = *in
@@ -217,6 +337,27 @@ func (in *File) DeepCopy() *File {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileContentsSource) DeepCopyInto(out *FileContentsSource) {
+	*out = *in
+	in.ConfigSource.DeepCopyInto(&out.ConfigSource)
+	if in.Compression != nil {
+		in, out := &in.Compression, &out.Compression
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileContentsSource.
+func (in *FileContentsSource) DeepCopy() *FileContentsSource {
+	if in == nil {
+		return nil
+	}
+	out := new(FileContentsSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FileEmbedded1) DeepCopyInto(out *FileEmbedded1) {
 	*out = *in
@@ -228,6 +369,11 @@ func (in *FileEmbedded1) DeepCopyInto(out *FileEmbedded1) {
 		}
 	}
 	in.Contents.DeepCopyInto(&out.Contents)
+	if in.ContentsFrom != nil {
+		in, out := &in.ContentsFrom, &out.ContentsFrom
+		*out = new(FileContentsSource)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Mode != nil {
 		in, out := &in.Mode, &out.Mode
 		*out = new(int)
@@ -360,14 +506,36 @@ func (in *IgnitionConfig) DeepCopyInto(out *IgnitionConfig) {
 	*out = *in
 	if in.Merge != nil {
 		in, out := &in.Merge, &out.Merge
-		*out = new(v1.LabelSelector)
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.Replace != nil {
 		in, out := &in.Replace, &out.Replace
-		*out = new(corev1.LocalObjectReference)
+		*out = new(v1.LocalObjectReference)
 		**out = **in
 	}
+	if in.MergeRefs != nil {
+		in, out := &in.MergeRefs, &out.MergeRefs
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterMerge != nil {
+		in, out := &in.ClusterMerge, &out.ClusterMerge
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MergeFrom != nil {
+		in, out := &in.MergeFrom, &out.MergeFrom
+		*out = make([]ConfigSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionConfig.
@@ -380,6 +548,21 @@ func (in *IgnitionConfig) DeepCopy() *IgnitionConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnitionReference) DeepCopyInto(out *IgnitionReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionReference.
+func (in *IgnitionReference) DeepCopy() *IgnitionReference {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnitionReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IgnitionV3) DeepCopyInto(out *IgnitionV3) {
 	*out = *in
@@ -407,6 +590,99 @@ func (in *IgnitionV3) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnitionV3Grant) DeepCopyInto(out *IgnitionV3Grant) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionV3Grant.
+func (in *IgnitionV3Grant) DeepCopy() *IgnitionV3Grant {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnitionV3Grant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IgnitionV3Grant) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnitionV3GrantFrom) DeepCopyInto(out *IgnitionV3GrantFrom) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionV3GrantFrom.
+func (in *IgnitionV3GrantFrom) DeepCopy() *IgnitionV3GrantFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnitionV3GrantFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnitionV3GrantList) DeepCopyInto(out *IgnitionV3GrantList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]IgnitionV3Grant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionV3GrantList.
+func (in *IgnitionV3GrantList) DeepCopy() *IgnitionV3GrantList {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnitionV3GrantList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IgnitionV3GrantList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnitionV3GrantSpec) DeepCopyInto(out *IgnitionV3GrantSpec) {
+	*out = *in
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = make([]IgnitionV3GrantFrom, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionV3GrantSpec.
+func (in *IgnitionV3GrantSpec) DeepCopy() *IgnitionV3GrantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnitionV3GrantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IgnitionV3List) DeepCopyInto(out *IgnitionV3List) {
 	*out = *in
@@ -439,14 +715,219 @@ func (in *IgnitionV3List) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnitionV3Output) DeepCopyInto(out *IgnitionV3Output) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(TargetSecret)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(TargetConfigMap)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionV3Output.
+func (in *IgnitionV3Output) DeepCopy() *IgnitionV3Output {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnitionV3Output)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnitionV3Pointer) DeepCopyInto(out *IgnitionV3Pointer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionV3Pointer.
+func (in *IgnitionV3Pointer) DeepCopy() *IgnitionV3Pointer {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnitionV3Pointer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnitionV3Set) DeepCopyInto(out *IgnitionV3Set) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionV3Set.
+func (in *IgnitionV3Set) DeepCopy() *IgnitionV3Set {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnitionV3Set)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IgnitionV3Set) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnitionV3SetInstance) DeepCopyInto(out *IgnitionV3SetInstance) {
+	*out = *in
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionV3SetInstance.
+func (in *IgnitionV3SetInstance) DeepCopy() *IgnitionV3SetInstance {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnitionV3SetInstance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnitionV3SetList) DeepCopyInto(out *IgnitionV3SetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]IgnitionV3Set, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionV3SetList.
+func (in *IgnitionV3SetList) DeepCopy() *IgnitionV3SetList {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnitionV3SetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IgnitionV3SetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnitionV3SetSpec) DeepCopyInto(out *IgnitionV3SetSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+	if in.Instances != nil {
+		in, out := &in.Instances, &out.Instances
+		*out = make([]IgnitionV3SetInstance, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InstancesFrom != nil {
+		in, out := &in.InstancesFrom, &out.InstancesFrom
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionV3SetSpec.
+func (in *IgnitionV3SetSpec) DeepCopy() *IgnitionV3SetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnitionV3SetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnitionV3SetStatus) DeepCopyInto(out *IgnitionV3SetStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionV3SetStatus.
+func (in *IgnitionV3SetStatus) DeepCopy() *IgnitionV3SetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnitionV3SetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IgnitionV3Spec) DeepCopyInto(out *IgnitionV3Spec) {
 	*out = *in
 	if in.TargetSecret != nil {
 		in, out := &in.TargetSecret, &out.TargetSecret
-		*out = new(corev1.LocalObjectReference)
+		*out = new(TargetSecret)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Raw != nil {
+		in, out := &in.Raw, &out.Raw
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.VariablesFrom != nil {
+		in, out := &in.VariablesFrom, &out.VariablesFrom
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.TokenFrom != nil {
+		in, out := &in.TokenFrom, &out.TokenFrom
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Pointer != nil {
+		in, out := &in.Pointer, &out.Pointer
+		*out = new(IgnitionV3Pointer)
 		**out = **in
 	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]IgnitionV3Output, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.Config.DeepCopyInto(&out.Config)
 }
 
@@ -465,14 +946,24 @@ func (in *IgnitionV3Status) DeepCopyInto(out *IgnitionV3Status) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
+		*out = make([]metav1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 	if in.TargetIgnitions != nil {
 		in, out := &in.TargetIgnitions, &out.TargetIgnitions
-		*out = make([]corev1.LocalObjectReference, len(*in))
+		*out = make([]IgnitionReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.MergedIgnitions != nil {
+		in, out := &in.MergedIgnitions, &out.MergedIgnitions
+		*out = make([]MergedIgnition, len(*in))
+		copy(*out, *in)
+	}
+	if in.Outputs != nil {
+		in, out := &in.Outputs, &out.Outputs
+		*out = make([]OutputStatus, len(*in))
 		copy(*out, *in)
 	}
 }
@@ -487,6 +978,29 @@ func (in *IgnitionV3Status) DeepCopy() *IgnitionV3Status {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IgnitionV3Template) DeepCopyInto(out *IgnitionV3Template) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IgnitionV3Template.
+func (in *IgnitionV3Template) DeepCopy() *IgnitionV3Template {
+	if in == nil {
+		return nil
+	}
+	out := new(IgnitionV3Template)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KernelArguments) DeepCopyInto(out *KernelArguments) {
 	*out = *in
@@ -607,6 +1121,111 @@ func (in *Luks) DeepCopy() *Luks {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineBinding) DeepCopyInto(out *MachineBinding) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineBinding.
+func (in *MachineBinding) DeepCopy() *MachineBinding {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineBinding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MachineBinding) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineBindingList) DeepCopyInto(out *MachineBindingList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MachineBinding, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineBindingList.
+func (in *MachineBindingList) DeepCopy() *MachineBindingList {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineBindingList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MachineBindingList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineBindingSpec) DeepCopyInto(out *MachineBindingSpec) {
+	*out = *in
+	out.Selector = in.Selector
+	out.Target = in.Target
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineBindingSpec.
+func (in *MachineBindingSpec) DeepCopy() *MachineBindingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineBindingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineSelector) DeepCopyInto(out *MachineSelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineSelector.
+func (in *MachineSelector) DeepCopy() *MachineSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MergedIgnition) DeepCopyInto(out *MergedIgnition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergedIgnition.
+func (in *MergedIgnition) DeepCopy() *MergedIgnition {
+	if in == nil {
+		return nil
+	}
+	out := new(MergedIgnition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Node) DeepCopyInto(out *Node) {
 	*out = *in
@@ -679,6 +1298,21 @@ func (in *NodeUser) DeepCopy() *NodeUser {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutputStatus) DeepCopyInto(out *OutputStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutputStatus.
+func (in *OutputStatus) DeepCopy() *OutputStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OutputStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Partition) DeepCopyInto(out *Partition) {
 	*out = *in
@@ -836,6 +1470,11 @@ func (in *PasswdUser) DeepCopyInto(out *PasswdUser) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.PasswordHashFrom != nil {
+		in, out := &in.PasswordHashFrom, &out.PasswordHashFrom
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.PrimaryGroup != nil {
 		in, out := &in.PrimaryGroup, &out.PrimaryGroup
 		*out = new(string)
@@ -846,6 +1485,13 @@ func (in *PasswdUser) DeepCopyInto(out *PasswdUser) {
 		*out = make([]SSHAuthorizedKey, len(*in))
 		copy(*out, *in)
 	}
+	if in.SSHAuthorizedKeysFrom != nil {
+		in, out := &in.SSHAuthorizedKeysFrom, &out.SSHAuthorizedKeysFrom
+		*out = make([]v1.SecretKeySelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Shell != nil {
 		in, out := &in.Shell, &out.Shell
 		*out = new(string)
@@ -1125,6 +1771,78 @@ func (in *Tang) DeepCopy() *Tang {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetConfigMap) DeepCopyInto(out *TargetConfigMap) {
+	*out = *in
+	if in.ExtraData != nil {
+		in, out := &in.ExtraData, &out.ExtraData
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetConfigMap.
+func (in *TargetConfigMap) DeepCopy() *TargetConfigMap {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetConfigMap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetSecret) DeepCopyInto(out *TargetSecret) {
+	*out = *in
+	if in.ExtraData != nil {
+		in, out := &in.ExtraData, &out.ExtraData
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetSecret.
+func (in *TargetSecret) DeepCopy() *TargetSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Timeouts) DeepCopyInto(out *Timeouts) {
 	*out = *in
@@ -24,6 +24,7 @@ import (
 
 	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
 	"github.com/cobaltcore-dev/khalkeon/internal/controller"
+	"github.com/cobaltcore-dev/khalkeon/internal/server"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -43,12 +44,15 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var ignitionAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&ignitionAddr, "ignition-bind-address", "0", "The address the ignition HTTP server binds to. "+
+		"Use :8080 to serve the configs of targets with a tokenFrom to machines, or leave as 0 to disable the server.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -138,8 +142,25 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "IgnitionV3")
 		os.Exit(1)
 	}
+	if err = (&controller.IgnitionV3SetReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "IgnitionV3Set")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
+	if ignitionAddr != "0" {
+		if err := mgr.Add(&server.IgnitionServer{
+			Client:      mgr.GetClient(),
+			BindAddress: ignitionAddr,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up ignition server")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+
+	butaneConfig "github.com/coreos/butane/config"
+	butaneCommon "github.com/coreos/butane/config/common"
+	ignitionConfig "github.com/coreos/ignition/v2/config/v3_5"
+	ignitiontypes "github.com/coreos/ignition/v2/config/v3_5/types"
+	"github.com/coreos/vcontext/report"
+)
+
+// translateButane translates a butane config to an ignition config of the newest supported version.
+// There is no files directory in the cluster, so local files and trees can't be used.
+func translateButane(butane string) (ignitiontypes.Config, []string, error) {
+	ignitionBytes, rpt, err := butaneConfig.TranslateBytes([]byte(butane), butaneCommon.TranslateBytesOptions{Raw: true})
+	if err != nil {
+		return ignitiontypes.Config{}, nil, fmt.Errorf("couldn't translate butane config. Error: %v, Report: %s", err, rpt.String())
+	}
+
+	cfg, ignitionRpt, err := ignitionConfig.ParseCompatibleVersion(ignitionBytes)
+	if err != nil || ignitionRpt.IsFatal() {
+		return ignitiontypes.Config{}, nil, fmt.Errorf("couldn't parse translated butane config. Error: %v, Report: %s", err, ignitionRpt.String())
+	}
+
+	warnings := []string{}
+	for _, entry := range rpt.Entries {
+		if entry.Kind == report.Warn {
+			warnings = append(warnings, entry.String())
+		}
+	}
+	return cfg, warnings, nil
+}
@@ -0,0 +1,226 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/coreos/ignition/v2/config/util"
+	ignitionConfig "github.com/coreos/ignition/v2/config/v3_5"
+	ignitiontypes "github.com/coreos/ignition/v2/config/v3_5/types"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
+)
+
+// configSourceRef is the flattened form of a ConfigSource.
+type configSourceRef struct {
+	kind     string
+	nn       types.NamespacedName
+	key      string
+	optional bool
+}
+
+func newConfigSourceRef(namespace string, source metalv1alpha1.ConfigSource) configSourceRef {
+	if source.SecretKeyRef != nil {
+		return configSourceRef{
+			kind:     metalv1alpha1.SecretKind,
+			nn:       types.NamespacedName{Namespace: namespace, Name: source.SecretKeyRef.Name},
+			key:      source.SecretKeyRef.Key,
+			optional: source.SecretKeyRef.Optional != nil && *source.SecretKeyRef.Optional,
+		}
+	}
+	return configSourceRef{
+		kind:     metalv1alpha1.ConfigMapKind,
+		nn:       types.NamespacedName{Namespace: namespace, Name: source.ConfigMapKeyRef.Name},
+		key:      source.ConfigMapKeyRef.Key,
+		optional: source.ConfigMapKeyRef.Optional != nil && *source.ConfigMapKeyRef.Optional,
+	}
+}
+
+func (ref configSourceRef) String() string {
+	return fmt.Sprintf("%s %s (key %s)", ref.kind, ref.nn.String(), ref.key)
+}
+
+// readConfigSource returns the content of the key selected by ref. The returned bool is false when the object or the key doesn't exist.
+func (r *IgnitionV3Reconciler) readConfigSource(ctx context.Context, ref configSourceRef) ([]byte, bool, error) {
+	var obj client.Object
+	switch ref.kind {
+	case metalv1alpha1.SecretKind:
+		obj = &corev1.Secret{}
+	default:
+		obj = &corev1.ConfigMap{}
+	}
+	if err := r.Get(ctx, ref.nn, obj); apierrors.IsNotFound(err) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, fmt.Errorf("couldn't get %s. Reason: %v", ref.kind, err)
+	}
+
+	switch o := obj.(type) {
+	case *corev1.Secret:
+		content, ok := o.Data[ref.key]
+		return content, ok, nil
+	case *corev1.ConfigMap:
+		if content, ok := o.Data[ref.key]; ok {
+			return []byte(content), true, nil
+		}
+		content, ok := o.BinaryData[ref.key]
+		return content, ok, nil
+	}
+	return nil, false, nil
+}
+
+// parseConfigSource parses the content of a config source and translates it to the newest supported version.
+// It returns the ignition version the content was written for.
+func parseConfigSource(ref configSourceRef, content []byte) (ignitiontypes.Config, string, error) {
+	version, _, err := util.GetConfigVersion(content)
+	if err != nil {
+		return ignitiontypes.Config{}, "", fmt.Errorf("couldn't parse %s into coreos ignition config. Reason: %v", ref.String(), err)
+	}
+	cfg, report, err := ignitionConfig.ParseCompatibleVersion(content)
+	if err != nil || report.IsFatal() {
+		return ignitiontypes.Config{}, "", fmt.Errorf("couldn't parse %s into coreos ignition config. Error: %v, Report: %s", ref.String(), err, report.String())
+	}
+	return cfg, version.String(), nil
+}
+
+// resolveConfigSource returns the content of the key selected by ref like readConfigSource.
+// Sources which don't exist and aren't optional are recorded in state, as are the Secrets which are read.
+func (r *IgnitionV3Reconciler) resolveConfigSource(ctx context.Context, ref configSourceRef, state *mergeState) ([]byte, bool, error) {
+	content, found, err := r.readConfigSource(ctx, ref)
+	if err == nil && !found && !ref.optional {
+		state.missingSources = append(state.missingSources, ref.String())
+	}
+	if err == nil && found && ref.kind == metalv1alpha1.SecretKind && !slices.Contains(state.secretSources, ref.nn.String()) {
+		state.secretSources = append(state.secretSources, ref.nn.String())
+	}
+	return content, found, err
+}
+
+// resolveContentsFrom returns a copy of spec in which the contents of files using contentsFrom are inlined as data URLs.
+// Sources which don't exist are recorded in state.
+func (r *IgnitionV3Reconciler) resolveContentsFrom(ctx context.Context, namespace string, spec metalv1alpha1.IgnitionV3Spec, state *mergeState) (metalv1alpha1.IgnitionV3Spec, error) {
+	files := make([]metalv1alpha1.File, len(spec.Storage.Files))
+	for i, file := range spec.Storage.Files {
+		files[i] = *file.DeepCopy()
+		if file.ContentsFrom == nil {
+			continue
+		}
+		ref := newConfigSourceRef(namespace, file.ContentsFrom.ConfigSource)
+		content, found, err := r.resolveConfigSource(ctx, ref, state)
+		if err != nil {
+			return metalv1alpha1.IgnitionV3Spec{}, err
+		}
+		if !found {
+			continue
+		}
+		if files[i].Contents, err = encodeFileContents(content, file.ContentsFrom.Compression); err != nil {
+			return metalv1alpha1.IgnitionV3Spec{}, fmt.Errorf("couldn't encode contents of %s. Reason: %v", ref.String(), err)
+		}
+		files[i].ContentsFrom = nil
+	}
+	spec.Storage.Files = files
+	return spec, nil
+}
+
+// resolveCredentialsFrom returns a copy of spec in which the password hashes and SSH authorized keys of users
+// using passwordHashFrom and sshAuthorizedKeysFrom are read from their Secrets. Secrets which don't exist are recorded in state.
+func (r *IgnitionV3Reconciler) resolveCredentialsFrom(ctx context.Context, namespace string, spec metalv1alpha1.IgnitionV3Spec, state *mergeState) (metalv1alpha1.IgnitionV3Spec, error) {
+	users := make([]metalv1alpha1.PasswdUser, len(spec.Passwd.Users))
+	for i, user := range spec.Passwd.Users {
+		users[i] = *user.DeepCopy()
+		if user.PasswordHashFrom != nil {
+			ref := newConfigSourceRef(namespace, metalv1alpha1.ConfigSource{SecretKeyRef: user.PasswordHashFrom})
+			content, found, err := r.resolveConfigSource(ctx, ref, state)
+			if err != nil {
+				return metalv1alpha1.IgnitionV3Spec{}, err
+			}
+			if found {
+				users[i].PasswordHash = ptr.To(strings.TrimSpace(string(content)))
+			}
+			users[i].PasswordHashFrom = nil
+		}
+		for _, selector := range user.SSHAuthorizedKeysFrom {
+			ref := newConfigSourceRef(namespace, metalv1alpha1.ConfigSource{SecretKeyRef: &selector})
+			content, found, err := r.resolveConfigSource(ctx, ref, state)
+			if err != nil {
+				return metalv1alpha1.IgnitionV3Spec{}, err
+			}
+			if !found {
+				continue
+			}
+			for _, line := range strings.Split(string(content), "\n") {
+				if key := strings.TrimSpace(line); key != "" && !strings.HasPrefix(key, "#") {
+					users[i].SSHAuthorizedKeys = append(users[i].SSHAuthorizedKeys, metalv1alpha1.SSHAuthorizedKey(key))
+				}
+			}
+		}
+		users[i].SSHAuthorizedKeysFrom = nil
+	}
+	spec.Passwd.Users = users
+	return spec, nil
+}
+
+// encodeFileContents returns a resource with content as base64 encoded data URL and the sha512 hash of content.
+// Ignition verifies the hash after decompressing, so it is computed before compressing.
+func encodeFileContents(content []byte, compression *string) (metalv1alpha1.Resource, error) {
+	hash := sha512.Sum512(content)
+	if compression != nil && *compression == "gzip" {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(content); err != nil {
+			return metalv1alpha1.Resource{}, err
+		}
+		if err := writer.Close(); err != nil {
+			return metalv1alpha1.Resource{}, err
+		}
+		content = buf.Bytes()
+	}
+
+	return metalv1alpha1.Resource{
+		Compression:  compression,
+		Source:       ptr.To("data:;base64," + base64.StdEncoding.EncodeToString(content)),
+		Verification: metalv1alpha1.Verification{Hash: ptr.To("sha512-" + hex.EncodeToString(hash[:]))},
+	}, nil
+}
+
+// configSources returns the Secrets and ConfigMaps an ignition reads with mergeFrom, contentsFrom,
+// passwordHashFrom, sshAuthorizedKeysFrom, variablesFrom and the tokenFrom of its pointer config.
+func configSources(ign *metalv1alpha1.IgnitionV3) []metalv1alpha1.ConfigSource {
+	sources := slices.Clone(ign.Spec.Ignition.Config.MergeFrom)
+	for _, file := range ign.Spec.Storage.Files {
+		if file.ContentsFrom != nil {
+			sources = append(sources, file.ContentsFrom.ConfigSource)
+		}
+	}
+	for _, user := range ign.Spec.Passwd.Users {
+		if user.PasswordHashFrom != nil {
+			sources = append(sources, metalv1alpha1.ConfigSource{SecretKeyRef: user.PasswordHashFrom})
+		}
+		for _, selector := range user.SSHAuthorizedKeysFrom {
+			sources = append(sources, metalv1alpha1.ConfigSource{SecretKeyRef: &selector})
+		}
+	}
+	if ign.Spec.Pointer != nil {
+		sources = append(sources, metalv1alpha1.ConfigSource{SecretKeyRef: ign.Spec.TokenFrom})
+	}
+	for _, ref := range ign.Spec.VariablesFrom {
+		// all keys of the ConfigMap are read, only its name is used for mapping
+		sources = append(sources, metalv1alpha1.ConfigSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: ref}})
+	}
+	return sources
+}
@@ -0,0 +1,249 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/coreos/ignition/v2/config/merge"
+	ignitiontypes "github.com/coreos/ignition/v2/config/v3_5/types"
+	vpath "github.com/coreos/vcontext/path"
+
+	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
+)
+
+// mergeConflict is a key defined with differing content by several merged objects, or a kernel argument
+// required by one merged object and forbidden by another.
+type mergeConflict struct {
+	// kind is the kind of the key, e.g. file or unit.
+	kind string
+	key  string
+	// origins lists the merged objects defining the key in the order they were merged.
+	origins []string
+}
+
+// conflictEntry is an entry of a config which can collide with an entry of the same kind and key of another config.
+type conflictEntry struct {
+	kind  string
+	key   string
+	value any
+	path  string
+}
+
+// conflictEntries returns the files, units, dropins, users, groups and filesystems of config.
+// Units are compared without their dropins, as dropins are compared on their own. Files are compared without
+// their appends and users without their SSH keys and groups, as merging adds them up instead of replacing them.
+func conflictEntries(config ignitiontypes.Config) []conflictEntry {
+	var entries []conflictEntry
+	add := func(kind, key string, value any, fieldPath ...any) {
+		entries = append(entries, conflictEntry{kind: kind, key: key, value: value, path: vpath.New(merge.TAG_RESULT, fieldPath...).String()})
+	}
+	for i, file := range config.Storage.Files {
+		file.Append = nil
+		add("file", file.Path, file, "storage", "files", i)
+	}
+	for i, filesystem := range config.Storage.Filesystems {
+		add("filesystem", filesystem.Device, filesystem, "storage", "filesystems", i)
+	}
+	for i, unit := range config.Systemd.Units {
+		for j, dropin := range unit.Dropins {
+			add("dropin", unit.Name+"/"+dropin.Name, dropin, "systemd", "units", i, "dropins", j)
+		}
+		unit.Dropins = nil
+		add("unit", unit.Name, unit, "systemd", "units", i)
+	}
+	for i, user := range config.Passwd.Users {
+		user.SSHAuthorizedKeys = nil
+		user.Groups = nil
+		add("user", user.Name, user, "passwd", "users", i)
+	}
+	for i, group := range config.Passwd.Groups {
+		add("group", group.Name, group, "passwd", "groups", i)
+	}
+	return entries
+}
+
+// detectConflicts returns the keys defined by both parent and child with differing content.
+// The objects defining them are looked up in the provenance of parent and child.
+func detectConflicts(parent ignitiontypes.Config, parentProvenance provenance, child ignitiontypes.Config, childProvenance provenance) []mergeConflict {
+	type entryKey struct{ kind, key string }
+	parentEntries := map[entryKey]conflictEntry{}
+	for _, entry := range conflictEntries(parent) {
+		parentEntries[entryKey{entry.kind, entry.key}] = entry
+	}
+
+	var conflicts []mergeConflict
+	for _, childEntry := range conflictEntries(child) {
+		parentEntry, ok := parentEntries[entryKey{childEntry.kind, childEntry.key}]
+		if !ok || reflect.DeepEqual(parentEntry.value, childEntry.value) {
+			continue
+		}
+		parentOrigin := parentProvenance[parentEntry.path]
+		childOrigin := childProvenance[childEntry.path]
+		if parentOrigin == childOrigin {
+			continue
+		}
+		conflicts = append(conflicts, mergeConflict{kind: childEntry.kind, key: childEntry.key, origins: []string{parentOrigin, childOrigin}})
+	}
+	return conflicts
+}
+
+// merge merges child into parent along with their provenance and records the conflicts between them.
+// Conflicts are resolved according to the conflict policy of the target ignition.
+func (s *mergeState) merge(parent ignitiontypes.Config, parentProvenance provenance, child ignitiontypes.Config, childProvenance provenance) (ignitiontypes.Config, provenance) {
+	aggregate := s.mergeStrategy == metalv1alpha1.MergeStrategyAggregate
+	// merging drops the kernel arguments of parent the child contradicts, so they are detected beforehand
+	conflicts := append(detectConflicts(parent, parentProvenance, child, childProvenance),
+		detectContradictions(parent, parentProvenance, child, childProvenance)...)
+	for _, conflict := range conflicts {
+		s.addConflict(conflict)
+	}
+	if len(conflicts) > 0 {
+		switch s.conflictPolicy {
+		case metalv1alpha1.ConflictPolicyFirstWins:
+			child, childProvenance = withoutConflicting(child, childProvenance, conflicts, aggregate)
+		case metalv1alpha1.ConflictPolicyLastWins:
+			parent, parentProvenance = withoutConflicting(parent, parentProvenance, conflicts, aggregate)
+		}
+	}
+	return mergeWithProvenance(parent, parentProvenance, child, childProvenance)
+}
+
+// withoutConflicting clears the conflicting entries of config apart from their keys, so they don't contribute
+// to the merged entries, and removes them from the provenance of config. Contradicting kernel arguments are dropped
+// from both lists of kernel arguments. The appends of conflicting files and
+// the SSH keys and groups of conflicting users are kept, as they don't take part in conflicts. The dropins of
+// conflicting units are kept when they are aggregated.
+func withoutConflicting(config ignitiontypes.Config, configProvenance provenance, conflicts []mergeConflict, aggregate bool) (ignitiontypes.Config, provenance) {
+	conflicting := map[string]bool{}
+	for _, conflict := range conflicts {
+		conflicting[conflict.kind+"/"+conflict.key] = true
+	}
+	configProvenance = maps.Clone(configProvenance)
+	clearEntry := func(kept []string, fieldPath ...any) {
+		prefix := vpath.New(merge.TAG_RESULT, fieldPath...).String()
+		for p := range configProvenance {
+			isKept := slices.ContainsFunc(kept, func(field string) bool { return strings.HasPrefix(p, prefix+"."+field) })
+			if p == prefix || (strings.HasPrefix(p, prefix+".") && !isKept) {
+				delete(configProvenance, p)
+			}
+		}
+	}
+
+	isConflicting := func(_ []ignitiontypes.KernelArgument, arg ignitiontypes.KernelArgument) bool {
+		return conflicting[kernelArgumentKind+"/"+string(arg)]
+	}
+	config.KernelArguments.ShouldExist = filterKernelArguments(config.KernelArguments.ShouldExist, configProvenance, "shouldExist", isConflicting)
+	config.KernelArguments.ShouldNotExist = filterKernelArguments(config.KernelArguments.ShouldNotExist, configProvenance, "shouldNotExist", isConflicting)
+
+	config.Storage.Files = slices.Clone(config.Storage.Files)
+	for i, file := range config.Storage.Files {
+		if conflicting["file/"+file.Path] {
+			config.Storage.Files[i] = ignitiontypes.File{Node: ignitiontypes.Node{Path: file.Path}, FileEmbedded1: ignitiontypes.FileEmbedded1{Append: file.Append}}
+			clearEntry([]string{"append"}, "storage", "files", i)
+		}
+	}
+	config.Storage.Filesystems = slices.Clone(config.Storage.Filesystems)
+	for i, filesystem := range config.Storage.Filesystems {
+		if conflicting["filesystem/"+filesystem.Device] {
+			config.Storage.Filesystems[i] = ignitiontypes.Filesystem{Device: filesystem.Device}
+			clearEntry(nil, "storage", "filesystems", i)
+		}
+	}
+	config.Systemd.Units = slices.Clone(config.Systemd.Units)
+	for i, unit := range config.Systemd.Units {
+		unit.Dropins = slices.Clone(unit.Dropins)
+		for j, dropin := range unit.Dropins {
+			if conflicting["dropin/"+unit.Name+"/"+dropin.Name] {
+				unit.Dropins[j] = ignitiontypes.Dropin{Name: dropin.Name}
+				clearEntry(nil, "systemd", "units", i, "dropins", j)
+			}
+		}
+		if conflicting["unit/"+unit.Name] {
+			cleared := ignitiontypes.Unit{Name: unit.Name}
+			var kept []string
+			if aggregate {
+				cleared.Dropins = unit.Dropins
+				kept = []string{"dropins"}
+			}
+			unit = cleared
+			clearEntry(kept, "systemd", "units", i)
+		}
+		config.Systemd.Units[i] = unit
+	}
+	config.Passwd.Users = slices.Clone(config.Passwd.Users)
+	for i, user := range config.Passwd.Users {
+		if conflicting["user/"+user.Name] {
+			config.Passwd.Users[i] = ignitiontypes.PasswdUser{Name: user.Name, SSHAuthorizedKeys: user.SSHAuthorizedKeys, Groups: user.Groups}
+			clearEntry([]string{"sshAuthorizedKeys", "groups"}, "passwd", "users", i)
+		}
+	}
+	config.Passwd.Groups = slices.Clone(config.Passwd.Groups)
+	for i, group := range config.Passwd.Groups {
+		if conflicting["group/"+group.Name] {
+			config.Passwd.Groups[i] = ignitiontypes.PasswdGroup{Name: group.Name}
+			clearEntry(nil, "passwd", "groups", i)
+		}
+	}
+	return config, configProvenance
+}
+
+// addConflict records conflict, adding its origins to a conflict of the same key recorded before.
+func (s *mergeState) addConflict(conflict mergeConflict) {
+	i := slices.IndexFunc(s.conflicts, func(c mergeConflict) bool { return c.kind == conflict.kind && c.key == conflict.key })
+	if i < 0 {
+		s.conflicts = append(s.conflicts, conflict)
+		return
+	}
+	for _, origin := range conflict.origins {
+		if !slices.Contains(s.conflicts[i].origins, origin) {
+			s.conflicts[i].origins = append(s.conflicts[i].origins, origin)
+		}
+	}
+}
+
+// mergeConflictsError is returned when merged objects conflict and the conflict policy of the target ignition is Fail.
+type mergeConflictsError struct {
+	conflicts []mergeConflict
+}
+
+func (e *mergeConflictsError) Error() string {
+	return fmt.Sprintf("merged objects define keys with differing content: %s", conflictsMessage(e.conflicts))
+}
+
+func (e *mergeConflictsError) reason() string {
+	return "MergeConflicts"
+}
+
+// conflictsMessage describes conflicts sorted by their kind and key.
+func conflictsMessage(conflicts []mergeConflict) string {
+	sorted := slices.SortedFunc(slices.Values(conflicts), func(a, b mergeConflict) int {
+		return cmp.Or(cmp.Compare(a.kind, b.kind), cmp.Compare(a.key, b.key))
+	})
+	messages := make([]string, 0, len(sorted))
+	for _, conflict := range sorted {
+		messages = append(messages, fmt.Sprintf("%s %s defined by %s", conflict.kind, conflict.key, strings.Join(conflict.origins, ", ")))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// dedupeAppends drops the appends of the files of config which are repeated from an earlier append of the same file.
+func dedupeAppends(config ignitiontypes.Config) ignitiontypes.Config {
+	config.Storage.Files = slices.Clone(config.Storage.Files)
+	for i, file := range config.Storage.Files {
+		var appends []ignitiontypes.Resource
+		for _, resource := range file.Append {
+			if !slices.ContainsFunc(appends, func(r ignitiontypes.Resource) bool { return reflect.DeepEqual(r, resource) }) {
+				appends = append(appends, resource)
+			}
+		}
+		config.Storage.Files[i].Append = appends
+	}
+	return config
+}
@@ -4,32 +4,41 @@
 package controller
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"slices"
 	"sort"
+	"strings"
 
 	ignitionConfig "github.com/coreos/ignition/v2/config/v3_5"
 	ignitiontypes "github.com/coreos/ignition/v2/config/v3_5/types"
+	"github.com/coreos/vcontext/report"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 
-	// "k8s.io/apimachinery/pkg/labels"
-
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
 )
 
-const secretConfigData = "config"
+const secretConfigData = metalv1alpha1.TargetSecretConfigKey
 
 var finalizer = metalv1alpha1.GroupVersion.Group + "/ignitionv3"
 
@@ -42,7 +51,12 @@ type IgnitionV3Reconciler struct {
 // +kubebuilder:rbac:groups=metal.cobaltcore.dev,resources=ignitionv3s,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=metal.cobaltcore.dev,resources=ignitionv3s/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=metal.cobaltcore.dev,resources=ignitionv3s/finalizers,verbs=update
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=list;watch;create;patch
+// +kubebuilder:rbac:groups=metal.cobaltcore.dev,resources=clusterignitionv3s,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metal.cobaltcore.dev,resources=clusterignitionv3s/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metal.cobaltcore.dev,resources=ignitionv3grants,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=list;watch;create;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=list;watch;create;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -88,33 +102,84 @@ func (r *IgnitionV3Reconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, nil
 	}
 
-	if err := r.patchConfigurationStatus(ctx, ignition); err != nil {
+	if ignition.Spec.TargetSecret == nil {
+		if err := r.patchConfigurationStatus(ctx, ignition, nil, nil, nil); err != nil {
+			return ctrl.Result{}, fmt.Errorf("couldn't patch configuration status: %w", err)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	state := newMergeState()
+	mergedConfig, mergedConfigBytes, mergeErr := r.renderMergedConfig(ctx, ignition, state)
+	storedConfigBytes, strategy := mergedConfigBytes, metalv1alpha1.OutputStrategyPlain
+	if mergeErr == nil {
+		storedConfigBytes, strategy, mergeErr = fitSecretSize(mergedConfigBytes, ignition.Spec.OutputVersion, configSizeLimit(ignition))
+	}
+	targetConfigBytes := storedConfigBytes
+	if mergeErr == nil && ignition.Spec.Pointer != nil {
+		targetConfigBytes, mergeErr = r.renderPointerConfig(ctx, ignition, storedConfigBytes, state)
+	}
+	if err := r.patchConfigurationStatus(ctx, ignition, mergeErr, state.mergedContradictions(), state.repeatedKernelArguments); err != nil {
 		return ctrl.Result{}, fmt.Errorf("couldn't patch configuration status: %w", err)
 	}
+	if err := r.patchConflictsStatus(ctx, ignition, state); err != nil {
+		return ctrl.Result{}, fmt.Errorf("couldn't patch conflicts status: %w", err)
+	}
+	var configErr configurationError
+	if errors.As(mergeErr, &configErr) {
+		// retrying doesn't help, the ignition is reconciled again once a grant, a merged ignition or a variable changes
+		return ctrl.Result{}, nil
+	}
+	if mergeErr != nil {
+		return ctrl.Result{}, fmt.Errorf("couldn't create merged configuration: %w", mergeErr)
+	}
 
-	if ignition.Spec.TargetSecret == nil {
+	if err := r.patchReferencesStatus(ctx, ignition, state); err != nil {
+		return ctrl.Result{}, fmt.Errorf("couldn't patch references status: %w", err)
+	}
+	if len(state.missingRefs) > 0 || len(state.missingSources) > 0 {
+		// the secret is kept unchanged until the missing objects are created
 		return ctrl.Result{}, nil
 	}
 
-	ignitions := map[string]struct{}{}
-	mergedConfig, err := r.createMergedConfig(ctx, ignition, ignitions)
-	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("couldn't create merged configuration: %w", err)
+	// the merged configuration is written before the pointer config referencing it
+	if ignition.Spec.Pointer != nil {
+		if err := r.reconcileRenderedSecret(ctx, ignition, storedConfigBytes); err != nil {
+			return r.handleNotOwned(ctx, ignition, fmt.Errorf("couldn't reconcile rendered secret: %w", err))
+		}
+	} else if err := r.deleteRenderedSecret(ctx, ignition); err != nil {
+		return ctrl.Result{}, fmt.Errorf("couldn't delete rendered secret: %w", err)
 	}
 
-	mergedConfigBytes, err := json.Marshal(mergedConfig)
-	if err != nil {
-		return ctrl.Result{}, fmt.Errorf("couldn't marshal merged configuration: %w", err)
+	summary := state.provenance.summarize(mergedConfig)
+	summary.countKeys(state.order)
+	// a provenance ConfigMap owned by others doesn't block the secret, it is reported in the Secret condition instead
+	provenanceErr := r.reconcileProvenanceConfigMap(ctx, ignition, summary)
+	var notOwnedErr *objectNotOwnedError
+	if provenanceErr != nil && !errors.As(provenanceErr, &notOwnedErr) {
+		return ctrl.Result{}, fmt.Errorf("couldn't reconcile provenance config map: %w", provenanceErr)
 	}
 
-	if err := r.reconcileSecret(ctx, ignition, mergedConfigBytes); err != nil {
+	if err := r.reconcileSecret(ctx, ignition, targetConfigBytes, provenanceErr); err != nil {
 		return ctrl.Result{}, fmt.Errorf("couldn't reconcile secret: %w", err)
 	}
 
-	if err := r.patchTargetIgnitionsStatus(ctx, ignitions, ignition); err != nil {
+	if err := r.reconcileOutputs(ctx, ignition, mergedConfig, state.secretSources); err != nil {
+		return ctrl.Result{}, fmt.Errorf("couldn't reconcile outputs: %w", err)
+	}
+
+	if err := r.patchTargetIgnitionsStatus(ctx, state.ignitions, ignition); err != nil {
 		return ctrl.Result{}, fmt.Errorf("couldn't patch target ignitions status: %w", err)
 	}
 
+	if err := r.patchMergedIgnitionsStatus(ctx, ignition, state.order); err != nil {
+		return ctrl.Result{}, fmt.Errorf("couldn't patch merged ignitions status: %w", err)
+	}
+
+	if err := r.patchOutputStatus(ctx, ignition, len(mergedConfigBytes), strategy); err != nil {
+		return ctrl.Result{}, fmt.Errorf("couldn't patch output status: %w", err)
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -122,8 +187,9 @@ func (r *IgnitionV3Reconciler) patchSecretStatus(ctx context.Context, reconcileI
 	if len(reconcileIgnition.Status.TargetIgnitions) == 0 && !isIgnitionCreated {
 		return nil
 	}
+	// targets can merge the ignition from other namespaces
 	ignitionList := &metalv1alpha1.IgnitionV3List{}
-	if err := r.List(ctx, ignitionList, &client.ListOptions{Namespace: reconcileIgnition.Namespace}); err != nil {
+	if err := r.List(ctx, ignitionList); err != nil {
 		return err
 	}
 	condition := metav1.Condition{
@@ -133,10 +199,10 @@ func (r *IgnitionV3Reconciler) patchSecretStatus(ctx context.Context, reconcileI
 		Reason:             "MergeResourceChanged",
 	}
 	for _, ign := range ignitionList.Items {
-		if reconcileIgnition.Name != ign.Name && isIgnitionCreated && ign.Spec.TargetSecret != nil {
+		if ign.Namespace == reconcileIgnition.Namespace && reconcileIgnition.Name != ign.Name && isIgnitionCreated && ign.Spec.TargetSecret != nil {
 			condition.Message = fmt.Sprintf("%s was created", client.ObjectKeyFromObject(reconcileIgnition).String())
-		} else if slices.ContainsFunc(reconcileIgnition.Status.TargetIgnitions,
-			func(ref corev1.LocalObjectReference) bool { return ref.Name == ign.Name }) {
+		} else if slices.Contains(reconcileIgnition.Status.TargetIgnitions,
+			metalv1alpha1.IgnitionReference{Namespace: ign.Namespace, Name: ign.Name}) {
 			condition.Message = fmt.Sprintf("%s has changed", client.ObjectKeyFromObject(reconcileIgnition).String())
 		} else {
 			continue
@@ -148,7 +214,9 @@ func (r *IgnitionV3Reconciler) patchSecretStatus(ctx context.Context, reconcileI
 	return nil
 }
 
-func (r *IgnitionV3Reconciler) patchConfigurationStatus(ctx context.Context, ignition *metalv1alpha1.IgnitionV3) error {
+// patchConfigurationStatus reports whether the ignition's spec converts and its merged configuration renders.
+// Repeated kernel arguments dropped from the merged configuration are reported as well.
+func (r *IgnitionV3Reconciler) patchConfigurationStatus(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, mergeErr error, contradictions []mergeConflict, repeatedArgs []string) error {
 	condition := metav1.Condition{
 		Type:               metalv1alpha1.ConfigurationType,
 		LastTransitionTime: metav1.Now(),
@@ -157,10 +225,85 @@ func (r *IgnitionV3Reconciler) patchConfigurationStatus(ctx context.Context, ign
 		Message:            "Specification is a valid ignition configuration",
 	}
 
-	if _, err := convert(ignition.Spec); err != nil {
+	var configErr configurationError
+	_, warnings, err := convertSpec(ignition.Spec)
+	if err != nil {
 		condition.Status = metav1.ConditionFalse
 		condition.Reason = "ConversionFailed"
 		condition.Message = err.Error()
+		return r.patchStatusIfNeeded(ctx, ignition, condition)
+	}
+	if errors.As(mergeErr, &configErr) {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = configErr.reason()
+		condition.Message = configErr.Error()
+		return r.patchStatusIfNeeded(ctx, ignition, condition)
+	}
+
+	// the configuration is valid, but everything it was changed or warned about is reported
+	reasons, messages := []string{}, []string{}
+	if len(warnings) > 0 {
+		reasons = append(reasons, "ConversionSucceededWithWarnings")
+		messages = append(messages, fmt.Sprintf("Butane translation reported warnings: %s", strings.Join(warnings, "; ")))
+	}
+	if len(contradictions) > 0 {
+		reasons = append(reasons, "KernelArgumentsContradictionsMerged")
+		messages = append(messages, fmt.Sprintf("Kernel arguments required and forbidden by different merged objects were merged, the object merged last wins: %s", contradictionsMessage(contradictions)))
+	}
+	if len(repeatedArgs) > 0 {
+		reasons = append(reasons, "KernelArgumentsDeduplicated")
+		messages = append(messages, fmt.Sprintf("Repeated kernel arguments were dropped: %s", strings.Join(repeatedArgs, ", ")))
+	}
+	if len(reasons) > 0 {
+		condition.Reason = strings.Join(reasons, ",")
+		condition.Message = strings.Join(messages, "; ")
+	}
+	return r.patchStatusIfNeeded(ctx, ignition, condition)
+}
+
+func (r *IgnitionV3Reconciler) patchReferencesStatus(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, state *mergeState) error {
+	condition := metav1.Condition{
+		Type:               metalv1alpha1.ReferencesType,
+		LastTransitionTime: metav1.Now(),
+		Status:             metav1.ConditionTrue,
+		Reason:             "ReferencesFound",
+		Message:            "All objects referenced by the ignition exist",
+	}
+
+	messages := []string{}
+	if len(state.missingRefs) > 0 {
+		names := make([]string, 0, len(state.missingRefs))
+		for _, nn := range state.missingRefs {
+			names = append(names, nn.String())
+		}
+		messages = append(messages, fmt.Sprintf("Referenced ignitions don't exist: %s", strings.Join(names, ", ")))
+	}
+	if len(state.missingSources) > 0 {
+		messages = append(messages, fmt.Sprintf("Referenced config sources don't exist: %s", strings.Join(state.missingSources, ", ")))
+	}
+	if len(messages) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ReferencesMissing"
+		condition.Message = strings.Join(messages, "; ")
+	}
+	return r.patchStatusIfNeeded(ctx, ignition, condition)
+}
+
+func (r *IgnitionV3Reconciler) patchConflictsStatus(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, state *mergeState) error {
+	condition := metav1.Condition{
+		Type:               metalv1alpha1.ConflictsType,
+		LastTransitionTime: metav1.Now(),
+		Status:             metav1.ConditionFalse,
+		Reason:             "NoConflicts",
+		Message:            "No key is defined with differing content by several merged objects",
+	}
+	if len(state.conflicts) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ConflictsDetected"
+		condition.Message = conflictsMessage(state.conflicts)
+		if state.conflictPolicy == metalv1alpha1.ConflictPolicyFirstWins || state.conflictPolicy == metalv1alpha1.ConflictPolicyLastWins {
+			condition.Reason = "ConflictsResolved"
+		}
 	}
 	return r.patchStatusIfNeeded(ctx, ignition, condition)
 }
@@ -175,88 +318,521 @@ func (r *IgnitionV3Reconciler) patchStatusIfNeeded(ctx context.Context, ignition
 	return nil
 }
 
-func (r *IgnitionV3Reconciler) createMergedConfig(ctx context.Context, ign *metalv1alpha1.IgnitionV3, collectedIgns map[string]struct{}) (ignitiontypes.Config, error) {
-	if _, isIgnCollected := collectedIgns[ign.Name]; isIgnCollected {
-		return ignitiontypes.Config{}, fmt.Errorf("loop with %s", client.ObjectKeyFromObject(ign).String())
+// renderMergedConfig creates the merged config of a target ignition, substitutes its variables and renders it
+// in the ignition's output version. The merged config is returned along with its rendering, so outputs can render
+// it in their own versions.
+func (r *IgnitionV3Reconciler) renderMergedConfig(ctx context.Context, ign *metalv1alpha1.IgnitionV3, state *mergeState) (ignitiontypes.Config, []byte, error) {
+	state.conflictPolicy = ign.Spec.ConflictPolicy
+	state.mergeStrategy = ign.Spec.MergeStrategy
+	mergedConfig, mergedProvenance, err := r.createMergedConfig(ctx, ign, state)
+	if err != nil {
+		return ignitiontypes.Config{}, nil, err
+	}
+	if state.mergeStrategy == metalv1alpha1.MergeStrategyAggregate {
+		mergedConfig = dedupeAppends(mergedConfig)
+	}
+	if len(state.conflicts) > 0 && state.conflictPolicy == metalv1alpha1.ConflictPolicyFail {
+		return ignitiontypes.Config{}, nil, &mergeConflictsError{conflicts: state.conflicts}
+	}
+	state.provenance = mergedProvenance
+	variables, err := r.resolveVariables(ctx, ign, state)
+	if err != nil {
+		return ignitiontypes.Config{}, nil, err
+	}
+	// placeholders aren't checked while referenced objects are missing, as the secret isn't updated anyway
+	if len(state.missingRefs) == 0 && len(state.missingSources) == 0 {
+		if mergedConfig, err = substituteVariables(mergedConfig, variables); err != nil {
+			return ignitiontypes.Config{}, nil, err
+		}
+	}
+	// substituted variables can repeat or contradict kernel arguments, so they are checked afterwards
+	mergedConfig, state.repeatedKernelArguments = state.checkKernelArguments(mergedConfig, state.provenance)
+	if len(state.contradictions) > 0 {
+		return ignitiontypes.Config{}, nil, &kernelArgumentsContradictionError{contradictions: state.contradictions}
+	}
+	configBytes, err := render(mergedConfig, ign.Spec.OutputVersion)
+	return mergedConfig, configBytes, err
+}
+
+// mergeState collects the ignitions taking part in creating a merged config.
+type mergeState struct {
+	// ignitions is used to detect loops and to update the status of the merged ignitions.
+	ignitions map[types.NamespacedName]struct{}
+	// order lists the merged ignitions in the order of their precedence, the last one wins.
+	order []metalv1alpha1.MergedIgnition
+	// missingRefs lists the ignitions referenced by mergeRefs which don't exist.
+	missingRefs []types.NamespacedName
+	// missingSources lists the referenced Secrets and ConfigMaps which don't exist.
+	missingSources []string
+	// secretSources lists the Secrets whose data is part of the merged config.
+	secretSources []string
+	// provenance records which merged object set each field of the merged config.
+	provenance provenance
+	// conflicts lists the keys defined with differing content by several merged objects and the contradicting kernel arguments.
+	conflicts []mergeConflict
+	// conflictPolicy is the conflict policy of the target ignition, it applies to all nested merges.
+	conflictPolicy string
+	// mergeStrategy is the merge strategy of the target ignition, it applies to all nested merges.
+	mergeStrategy string
+	// contradictions lists the kernel arguments the merged config requires and forbids once variables are substituted.
+	contradictions []kernelArgumentContradiction
+	// repeatedKernelArguments lists the kernel arguments dropped from the merged config as they were repeated.
+	repeatedKernelArguments []string
+	// clusterPath lists the cluster ignitions whose clusterMerge is being merged, so loops are told apart from
+	// cluster ignitions selected more than once.
+	clusterPath []string
+}
+
+func newMergeState() *mergeState {
+	return &mergeState{ignitions: map[types.NamespacedName]struct{}{}}
+}
+
+// collect registers an ignition and reports whether it was collected before.
+func (s *mergeState) collect(nn types.NamespacedName) bool {
+	if _, isIgnCollected := s.ignitions[nn]; isIgnCollected {
+		return true
+	}
+	s.ignitions[nn] = struct{}{}
+	return false
+}
+
+// createMergedConfig creates the merged config of an ignition along with its provenance.
+func (r *IgnitionV3Reconciler) createMergedConfig(ctx context.Context, ign *metalv1alpha1.IgnitionV3, state *mergeState) (ignitiontypes.Config, provenance, error) {
+	if isIgnCollected := state.collect(client.ObjectKeyFromObject(ign)); isIgnCollected {
+		return ignitiontypes.Config{}, nil, fmt.Errorf("loop with %s", client.ObjectKeyFromObject(ign).String())
 	}
-	collectedIgns[ign.Name] = struct{}{}
 
 	if ign.Spec.Ignition.Config.Replace != nil {
 		replaceIng := &metalv1alpha1.IgnitionV3{}
 		nn := types.NamespacedName{Name: ign.Spec.Ignition.Config.Replace.Name, Namespace: ign.Namespace}
 		if err := r.Get(ctx, nn, replaceIng); err != nil {
-			return ignitiontypes.Config{}, fmt.Errorf("couldn't get ignition. Reason: %v", err)
+			return ignitiontypes.Config{}, nil, fmt.Errorf("couldn't get ignition. Reason: %v", err)
 		}
-		return r.createMergedConfig(ctx, replaceIng, collectedIgns)
+		return r.createMergedConfig(ctx, replaceIng, state)
+	}
+
+	merged := metalv1alpha1.MergedIgnition{
+		Kind:      metalv1alpha1.IgnitionV3Kind,
+		Namespace: ign.Namespace,
+		Name:      ign.Name,
+		Priority:  ign.Spec.Priority,
+		Version:   ign.Spec.Ignition.Version,
 	}
+	state.order = append(state.order, merged)
 
-	config, err := convert(ign.Spec)
+	spec, err := r.resolveContentsFrom(ctx, ign.Namespace, ign.Spec, state)
 	if err != nil {
-		return ignitiontypes.Config{}, fmt.Errorf("couldn't convert ignition spec. Reason: %v", err)
+		return ignitiontypes.Config{}, nil, err
+	}
+	if spec, err = r.resolveCredentialsFrom(ctx, ign.Namespace, spec, state); err != nil {
+		return ignitiontypes.Config{}, nil, err
+	}
+	config, _, err := convertSpec(spec)
+	if err != nil {
+		return ignitiontypes.Config{}, nil, fmt.Errorf("couldn't convert ignition spec. Reason: %v", err)
+	}
+	configProvenance := newProvenance(config, originOf(merged))
+	config, configProvenance, err = r.mergeClusterIgnitions(ctx, config, configProvenance, ign.Spec.Ignition.Config.ClusterMerge, state)
+	if err != nil {
+		return ignitiontypes.Config{}, nil, err
 	}
 
 	if ign.Spec.Ignition.Config.Merge != nil {
-		selector, err := metav1.LabelSelectorAsSelector(ign.Spec.Ignition.Config.Merge)
+		ignitions, err := r.listMergeIgnitions(ctx, ign)
 		if err != nil {
-			return ignitiontypes.Config{}, fmt.Errorf("couldn't convert ignition merge label selector. Reason: %v", err)
+			return ignitiontypes.Config{}, nil, err
 		}
 
-		ignitionList := metalv1alpha1.IgnitionV3List{}
-		if err := r.List(ctx, &ignitionList, &client.ListOptions{LabelSelector: selector, Namespace: ign.Namespace}); err != nil {
-			return ignitiontypes.Config{}, fmt.Errorf("couldn't list ignitions. Reason: %v", err)
+		mergedConfig, mergedProvenance := ignitiontypes.Config{}, provenance{}
+		for _, ignition := range ignitions {
+			cfg, cfgProvenance, err := r.createMergedConfig(ctx, &ignition, state)
+			if err != nil {
+				return ignitiontypes.Config{}, nil, err
+			}
+			mergedConfig, mergedProvenance = state.merge(mergedConfig, mergedProvenance, cfg, cfgProvenance)
 		}
 
-		indices := make([]int, len(ignitionList.Items))
-		for i := range ignitionList.Items {
-			indices[i] = i
+		config, configProvenance = state.merge(config, configProvenance, mergedConfig, mergedProvenance)
+	}
+
+	for _, ref := range ign.Spec.Ignition.Config.MergeRefs {
+		refIgn := &metalv1alpha1.IgnitionV3{}
+		nn := types.NamespacedName{Name: ref.Name, Namespace: ign.Namespace}
+		if err := r.Get(ctx, nn, refIgn); apierrors.IsNotFound(err) {
+			state.missingRefs = append(state.missingRefs, nn)
+			continue
+		} else if err != nil {
+			return ignitiontypes.Config{}, nil, fmt.Errorf("couldn't get ignition. Reason: %v", err)
 		}
-		sort.Slice(indices, func(i, j int) bool {
-			return ignitionList.Items[i].Name < ignitionList.Items[j].Name
-		})
+		cfg, cfgProvenance, err := r.createMergedConfig(ctx, refIgn, state)
+		if err != nil {
+			return ignitiontypes.Config{}, nil, err
+		}
+		config, configProvenance = state.merge(config, configProvenance, cfg, cfgProvenance)
+	}
+
+	for _, source := range ign.Spec.Ignition.Config.MergeFrom {
+		ref := newConfigSourceRef(ign.Namespace, source)
+		content, found, err := r.resolveConfigSource(ctx, ref, state)
+		if err != nil {
+			return ignitiontypes.Config{}, nil, err
+		}
+		if !found {
+			continue
+		}
+		cfg, version, err := parseConfigSource(ref, content)
+		if err != nil {
+			return ignitiontypes.Config{}, nil, err
+		}
+		mergedSource := metalv1alpha1.MergedIgnition{
+			Kind:      ref.kind,
+			Namespace: ref.nn.Namespace,
+			Name:      ref.nn.Name,
+			Version:   version,
+		}
+		state.order = append(state.order, mergedSource)
+		config, configProvenance = state.merge(config, configProvenance, cfg, newProvenance(cfg, originOf(mergedSource)))
+	}
+
+	return config, configProvenance, err
+}
 
-		mergedConfig := ignitiontypes.Config{}
-		for _, i := range indices {
-			ignition := ignitionList.Items[i] // iterating through ignitions sorted by their name to ensure deterministic output
-			cfg, err := r.createMergedConfig(ctx, &ignition, collectedIgns)
+// listMergeIgnitions lists the ignitions selected by merge in the ignition's namespace and in the namespaces selected
+// by namespaceSelector. The ignitions are sorted by their priority, name and namespace to ensure deterministic output.
+func (r *IgnitionV3Reconciler) listMergeIgnitions(ctx context.Context, ign *metalv1alpha1.IgnitionV3) ([]metalv1alpha1.IgnitionV3, error) {
+	selector, err := metav1.LabelSelectorAsSelector(ign.Spec.Ignition.Config.Merge)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't convert ignition merge label selector. Reason: %v", err)
+	}
+
+	namespaces := []string{ign.Namespace}
+	if ign.Spec.Ignition.Config.NamespaceSelector != nil {
+		namespaceSelector, err := metav1.LabelSelectorAsSelector(ign.Spec.Ignition.Config.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't convert ignition namespace selector. Reason: %v", err)
+		}
+		namespaceList := &corev1.NamespaceList{}
+		if err := r.List(ctx, namespaceList, &client.ListOptions{LabelSelector: namespaceSelector}); err != nil {
+			return nil, fmt.Errorf("couldn't list namespaces. Reason: %v", err)
+		}
+		for _, namespace := range namespaceList.Items {
+			if namespace.Name != ign.Namespace {
+				namespaces = append(namespaces, namespace.Name)
+			}
+		}
+	}
+
+	ignitions := []metalv1alpha1.IgnitionV3{}
+	for _, namespace := range namespaces {
+		ignitionList := metalv1alpha1.IgnitionV3List{}
+		if err := r.List(ctx, &ignitionList, &client.ListOptions{LabelSelector: selector, Namespace: namespace}); err != nil {
+			return nil, fmt.Errorf("couldn't list ignitions. Reason: %v", err)
+		}
+		if namespace != ign.Namespace && len(ignitionList.Items) > 0 {
+			isGranted, err := r.isMergeGranted(ctx, namespace, ign.Namespace)
 			if err != nil {
-				return ignitiontypes.Config{}, err
+				return nil, err
+			}
+			if !isGranted {
+				return nil, &mergeNotPermittedError{namespace: ign.Namespace, sourceNamespace: namespace}
 			}
-			mergedConfig = ignitionConfig.Merge(mergedConfig, cfg)
 		}
+		ignitions = append(ignitions, ignitionList.Items...)
+	}
+
+	sort.Slice(ignitions, func(i, j int) bool {
+		if ignitions[i].Spec.Priority != ignitions[j].Spec.Priority {
+			return ignitions[i].Spec.Priority < ignitions[j].Spec.Priority
+		}
+		if ignitions[i].Name != ignitions[j].Name {
+			return ignitions[i].Name < ignitions[j].Name
+		}
+		return ignitions[i].Namespace < ignitions[j].Namespace
+	})
+	return ignitions, nil
+}
+
+// isMergeGranted checks whether an IgnitionV3Grant in sourceNamespace allows namespace to merge its ignitions.
+func (r *IgnitionV3Reconciler) isMergeGranted(ctx context.Context, sourceNamespace, namespace string) (bool, error) {
+	grantList := &metalv1alpha1.IgnitionV3GrantList{}
+	if err := r.List(ctx, grantList, &client.ListOptions{Namespace: sourceNamespace}); err != nil {
+		return false, fmt.Errorf("couldn't list ignition grants. Reason: %v", err)
+	}
+	for _, grant := range grantList.Items {
+		if slices.Contains(grant.Spec.From, metalv1alpha1.IgnitionV3GrantFrom{Namespace: namespace}) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// configurationError is an error of the merged configuration of a target ignition which retrying doesn't resolve.
+// It is reported in the Configuration condition with its reason.
+type configurationError interface {
+	error
+	reason() string
+}
+
+// objectNotOwnedError is returned when an object the controller derives from a target ignition already exists
+// without being owned by it, so it isn't overwritten.
+type objectNotOwnedError struct {
+	kind string
+	nn   types.NamespacedName
+}
+
+func (e *objectNotOwnedError) Error() string {
+	return fmt.Sprintf("%s %s exists and isn't owned by the ignition", e.kind, e.nn.String())
+}
 
-		config = ignitionConfig.Merge(config, mergedConfig)
+// checkOwned returns an objectNotOwnedError when obj exists without being owned by ignition.
+func checkOwned(obj client.Object, ignition *metalv1alpha1.IgnitionV3) error {
+	if creationTimestamp := obj.GetCreationTimestamp(); creationTimestamp.IsZero() || isOwnedBy(obj, ignition) {
+		return nil
+	}
+	kind := metalv1alpha1.SecretKind
+	if _, ok := obj.(*corev1.ConfigMap); ok {
+		kind = metalv1alpha1.ConfigMapKind
 	}
+	return &objectNotOwnedError{kind: kind, nn: client.ObjectKeyFromObject(obj)}
+}
 
-	return config, err
+// isOwnedBy reports whether ignition is an owner of obj.
+func isOwnedBy(obj metav1.Object, ignition *metalv1alpha1.IgnitionV3) bool {
+	return slices.ContainsFunc(obj.GetOwnerReferences(), func(ref metav1.OwnerReference) bool { return ref.UID == ignition.UID })
 }
 
-func convert(spec metalv1alpha1.IgnitionV3Spec) (ignitiontypes.Config, error) {
+// handleNotOwned reports an objectNotOwnedError in the Secret condition of ignition instead of returning it,
+// other errors are returned.
+func (r *IgnitionV3Reconciler) handleNotOwned(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, err error) (ctrl.Result, error) {
+	var notOwnedErr *objectNotOwnedError
+	if !errors.As(err, &notOwnedErr) {
+		return ctrl.Result{}, err
+	}
+	condition := metav1.Condition{
+		Type:               metalv1alpha1.SecretType,
+		LastTransitionTime: metav1.Now(),
+		Status:             metav1.ConditionFalse,
+		Reason:             "ObjectNotOwned",
+		Message:            notOwnedErr.Error(),
+	}
+	// retrying doesn't help, the ignition is reconciled again once it changes
+	return ctrl.Result{}, r.patchStatusIfNeeded(ctx, ignition, condition)
+}
+
+// mergeNotPermittedError is returned when ignitions are merged from a namespace which doesn't grant access to them.
+type mergeNotPermittedError struct {
+	namespace       string
+	sourceNamespace string
+}
+
+func (e *mergeNotPermittedError) Error() string {
+	return fmt.Sprintf("namespace %s doesn't grant namespace %s to merge its ignitions", e.sourceNamespace, e.namespace)
+}
+
+func (e *mergeNotPermittedError) reason() string {
+	return "MergeNotPermitted"
+}
+
+// clusterIgnitionLoopError is returned when cluster ignitions select each other with clusterMerge.
+type clusterIgnitionLoopError struct {
+	path []string
+}
+
+func (e *clusterIgnitionLoopError) Error() string {
+	return fmt.Sprintf("cluster ignitions select each other with clusterMerge: %s", strings.Join(e.path, " -> "))
+}
+
+func (e *clusterIgnitionLoopError) reason() string {
+	return "ClusterIgnitionLoop"
+}
+
+// mergeClusterIgnitions merges the ClusterIgnitionV3 objects selected by clusterMerge into config and its provenance.
+func (r *IgnitionV3Reconciler) mergeClusterIgnitions(ctx context.Context, config ignitiontypes.Config, configProvenance provenance, clusterMerge *metav1.LabelSelector, state *mergeState) (ignitiontypes.Config, provenance, error) {
+	if clusterMerge == nil {
+		return config, configProvenance, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(clusterMerge)
+	if err != nil {
+		return ignitiontypes.Config{}, nil, fmt.Errorf("couldn't convert ignition cluster merge label selector. Reason: %v", err)
+	}
+
+	clusterIgnitionList := metalv1alpha1.ClusterIgnitionV3List{}
+	if err := r.List(ctx, &clusterIgnitionList, &client.ListOptions{LabelSelector: selector}); err != nil {
+		return ignitiontypes.Config{}, nil, fmt.Errorf("couldn't list cluster ignitions. Reason: %v", err)
+	}
+	sort.Slice(clusterIgnitionList.Items, func(i, j int) bool {
+		a, b := clusterIgnitionList.Items[i], clusterIgnitionList.Items[j]
+		if a.Spec.Priority != b.Spec.Priority {
+			return a.Spec.Priority < b.Spec.Priority
+		}
+		return a.Name < b.Name
+	})
+
+	mergedConfig, mergedProvenance := ignitiontypes.Config{}, provenance{}
+	for _, clusterIgnition := range clusterIgnitionList.Items {
+		if slices.Contains(state.clusterPath, clusterIgnition.Name) {
+			return ignitiontypes.Config{}, nil, &clusterIgnitionLoopError{path: append(slices.Clone(state.clusterPath), clusterIgnition.Name)}
+		}
+		if isIgnCollected := state.collect(client.ObjectKeyFromObject(&clusterIgnition)); isIgnCollected {
+			// a cluster ignition selected more than once is merged where it was selected first
+			continue
+		}
+		merged := metalv1alpha1.MergedIgnition{
+			Kind:     metalv1alpha1.ClusterIgnitionV3Kind,
+			Name:     clusterIgnition.Name,
+			Priority: clusterIgnition.Spec.Priority,
+			Version:  clusterIgnition.Spec.Ignition.Version,
+		}
+		state.order = append(state.order, merged)
+
+		cfg, err := convert(clusterIgnition.Spec.Config)
+		if err != nil {
+			return ignitiontypes.Config{}, nil, fmt.Errorf("couldn't convert cluster ignition spec. Reason: %v", err)
+		}
+		state.clusterPath = append(state.clusterPath, clusterIgnition.Name)
+		cfg, cfgProvenance, err := r.mergeClusterIgnitions(ctx, cfg, newProvenance(cfg, originOf(merged)), clusterIgnition.Spec.Ignition.Config.ClusterMerge, state)
+		state.clusterPath = state.clusterPath[:len(state.clusterPath)-1]
+		if err != nil {
+			return ignitiontypes.Config{}, nil, err
+		}
+		mergedConfig, mergedProvenance = state.merge(mergedConfig, mergedProvenance, cfg, cfgProvenance)
+	}
+
+	config, configProvenance = state.merge(config, configProvenance, mergedConfig, mergedProvenance)
+	return config, configProvenance, nil
+}
+
+// convertSpec converts the ignition config of spec and merges the raw and the translated butane config into it.
+// It returns the warnings of the butane translation.
+func convertSpec(spec metalv1alpha1.IgnitionV3Spec) (ignitiontypes.Config, []string, error) {
+	config, err := convert(spec.Config)
+	if err != nil {
+		return ignitiontypes.Config{}, nil, err
+	}
+
+	if spec.Raw != nil && len(spec.Raw.Raw) > 0 {
+		raw, report, err := ignitionConfig.ParseCompatibleVersion(spec.Raw.Raw)
+		if err != nil || report.IsFatal() {
+			return ignitiontypes.Config{}, nil, fmt.Errorf("couldn't parse raw config into coreos ignition config. Error: %v, Report: %s", err, report.String())
+		}
+		config = ignitionConfig.Merge(config, raw)
+	}
+
+	if spec.Butane == "" {
+		return config, nil, nil
+	}
+	butane, warnings, err := translateButane(spec.Butane)
+	if err != nil {
+		return ignitiontypes.Config{}, nil, err
+	}
+	return ignitionConfig.Merge(config, butane), warnings, nil
+}
+
+// convert parses spec according to its ignition version and translates it to the newest supported version.
+func convert(spec metalv1alpha1.Config) (ignitiontypes.Config, error) {
 	spec.Ignition.Config.Merge = nil
+	spec.Ignition.Config.NamespaceSelector = nil
 	spec.Ignition.Config.Replace = nil
-	spec.TargetSecret = nil
+	spec.Ignition.Config.MergeRefs = nil
+	spec.Ignition.Config.ClusterMerge = nil
+	spec.Ignition.Config.MergeFrom = nil
+	// contentsFrom, passwordHashFrom and sshAuthorizedKeysFrom are resolved by the reconciler before merging
+	files := make([]metalv1alpha1.File, len(spec.Storage.Files))
+	for i, file := range spec.Storage.Files {
+		file.ContentsFrom = nil
+		files[i] = file
+	}
+	spec.Storage.Files = files
+	users := make([]metalv1alpha1.PasswdUser, len(spec.Passwd.Users))
+	for i, user := range spec.Passwd.Users {
+		user.PasswordHashFrom = nil
+		user.SSHAuthorizedKeysFrom = nil
+		users[i] = user
+	}
+	spec.Passwd.Users = users
 
 	specByte, err := json.Marshal(spec)
 	if err != nil {
 		return ignitiontypes.Config{}, fmt.Errorf("couldn't marshal spec. Reason: %v", err)
 	}
 
-	cfg, report, err := ignitionConfig.Parse(specByte)
+	if spec.Ignition.Version != ignitiontypes.MaxVersion.String() {
+		// older specifications don't know all fields of the API, empty ones are dropped
+		// so that only the fields which are really used are reported as unsupported
+		if specByte, err = pruneEmptyFields(specByte); err != nil {
+			return ignitiontypes.Config{}, fmt.Errorf("couldn't prune spec. Reason: %v", err)
+		}
+	}
+
+	cfg, report, err := ignitionConfig.ParseCompatibleVersion(specByte)
 	if err != nil || report.IsFatal() {
 		return ignitiontypes.Config{}, fmt.Errorf("couldn't parse spec into coreos ignition config. Error: %v, Report: %s", err, report.String())
 	}
+	if unusedKeys := unusedKeys(report); len(unusedKeys) > 0 {
+		return ignitiontypes.Config{}, fmt.Errorf("ignition version %s doesn't support %s", spec.Ignition.Version, strings.Join(unusedKeys, ", "))
+	}
 	return cfg, nil
 }
 
-func (r *IgnitionV3Reconciler) reconcileSecret(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, configBytes []byte) error {
+// pruneEmptyFields removes empty objects and arrays from a JSON document.
+func pruneEmptyFields(data []byte) ([]byte, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var prune func(value any) (any, bool)
+	prune = func(value any) (any, bool) {
+		switch v := value.(type) {
+		case map[string]any:
+			for key, field := range v {
+				if pruned, isEmpty := prune(field); isEmpty {
+					delete(v, key)
+				} else {
+					v[key] = pruned
+				}
+			}
+			return v, len(v) == 0
+		case []any:
+			for i, item := range v {
+				v[i], _ = prune(item)
+			}
+			return v, len(v) == 0
+		}
+		return value, false
+	}
+	doc, _ = prune(doc)
+	return json.Marshal(doc)
+}
+
+// unusedKeys returns the paths of the keys which the ignition parser didn't recognize.
+func unusedKeys(rpt report.Report) []string {
+	keys := []string{}
+	for _, entry := range rpt.Entries {
+		if entry.Kind == report.Warn && strings.HasPrefix(entry.Message, "Unused key") {
+			keys = append(keys, entry.Context.String())
+		}
+	}
+	return keys
+}
+
+// reconcileSecret writes configBytes to the TargetSecret of a target ignition along with its extra data, labels and annotations.
+// When the provenance ConfigMap couldn't be written, provenanceErr is reported and the secret doesn't reference it.
+func (r *IgnitionV3Reconciler) reconcileSecret(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, configBytes []byte, provenanceErr error) error {
+	target := ignition.Spec.TargetSecret
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      ignition.Spec.TargetSecret.Name,
+			Name:      target.Name,
 			Namespace: ignition.Namespace,
 		},
-		Data: map[string][]byte{secretConfigData: configBytes},
+	}
+	extraAnnotations := map[string]string{metalv1alpha1.ProvenanceAnnotation: ignition.Name + metalv1alpha1.ProvenanceConfigMapSuffix}
+	if provenanceErr != nil {
+		extraAnnotations = nil
 	}
 	res, err := controllerutil.CreateOrPatch(ctx, r.Client, secret, func() error {
-		secret.Data[secretConfigData] = configBytes
+		applyTargetSecret(secret, target, configBytes, extraAnnotations)
 		return controllerutil.SetOwnerReference(ignition, secret, r.Scheme)
 	})
 
@@ -267,6 +843,10 @@ func (r *IgnitionV3Reconciler) reconcileSecret(ctx context.Context, ignition *me
 			Status:             metav1.ConditionTrue,
 			Reason:             "SecretReady",
 		}
+		if provenanceErr != nil {
+			condition.Reason = "ProvenanceNotOwned"
+			condition.Message = provenanceErr.Error()
+		}
 		if err := r.patchStatusIfNeeded(ctx, ignition, condition); err != nil {
 			return err
 		}
@@ -274,18 +854,103 @@ func (r *IgnitionV3Reconciler) reconcileSecret(ctx context.Context, ignition *me
 	return err
 }
 
-func (r *IgnitionV3Reconciler) patchTargetIgnitionsStatus(ctx context.Context, ignitions map[string]struct{}, targetIgnition *metalv1alpha1.IgnitionV3) error {
+// applyTargetSecret sets the type, data, labels and annotations described by target on secret, along with
+// further annotations of the controller.
+func applyTargetSecret(secret *corev1.Secret, target *metalv1alpha1.TargetSecret, configBytes []byte, extraAnnotations map[string]string) {
+	if secret.CreationTimestamp.IsZero() {
+		// the type of a Secret is immutable
+		secret.Type = target.Type
+	}
+	data := map[string][]byte{targetSecretKey(target): configBytes}
+	for key, value := range target.ExtraData {
+		data[key] = []byte(value)
+	}
+	managed := managedKeys(secret, metalv1alpha1.ManagedDataAnnotation)
+	if _, isRecorded := secret.Annotations[metalv1alpha1.ManagedDataAnnotation]; !isRecorded && !secret.CreationTimestamp.IsZero() {
+		// secrets written before the data keys were recorded only hold the merged configuration at the default key
+		managed = []string{secretConfigData}
+	}
+	secret.Data = applyManaged(secret.Data, data, managed)
+	recordManagedKeys(secret, metalv1alpha1.ManagedDataAnnotation, data)
+
+	annotations := maps.Clone(target.Annotations)
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	maps.Copy(annotations, extraAnnotations)
+	applyMetadata(secret, target.Labels, annotations)
+}
+
+// applyMetadata sets labels and annotations on obj, keeping the ones set by others. Labels and annotations
+// set before which aren't passed anymore are removed.
+func applyMetadata(obj metav1.Object, addedLabels, addedAnnotations map[string]string) {
+	obj.SetLabels(applyManaged(obj.GetLabels(), addedLabels, managedKeys(obj, metalv1alpha1.ManagedLabelsAnnotation)))
+	obj.SetAnnotations(applyManaged(obj.GetAnnotations(), addedAnnotations, managedKeys(obj, metalv1alpha1.ManagedAnnotationsAnnotation)))
+	recordManagedKeys(obj, metalv1alpha1.ManagedLabelsAnnotation, addedLabels)
+	recordManagedKeys(obj, metalv1alpha1.ManagedAnnotationsAnnotation, addedAnnotations)
+}
+
+// applyManaged sets values in current and deletes the managed keys which values doesn't contain anymore.
+// Keys which aren't managed are kept.
+func applyManaged[V any](current, values map[string]V, managed []string) map[string]V {
+	for _, key := range managed {
+		if _, ok := values[key]; !ok {
+			delete(current, key)
+		}
+	}
+	if len(values) == 0 {
+		return current
+	}
+	if current == nil {
+		current = map[string]V{}
+	}
+	maps.Copy(current, values)
+	return current
+}
+
+// managedKeys returns the keys recorded in the annotation of obj.
+func managedKeys(obj metav1.Object, annotation string) []string {
+	value := obj.GetAnnotations()[annotation]
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// recordManagedKeys records the keys of values in the annotation of obj, so they can be removed once they aren't
+// set anymore.
+func recordManagedKeys[V any](obj metav1.Object, annotation string, values map[string]V) {
+	objAnnotations := obj.GetAnnotations()
+	if len(values) == 0 {
+		delete(objAnnotations, annotation)
+		obj.SetAnnotations(objAnnotations)
+		return
+	}
+	if objAnnotations == nil {
+		objAnnotations = map[string]string{}
+	}
+	objAnnotations[annotation] = strings.Join(slices.Sorted(maps.Keys(values)), ",")
+	obj.SetAnnotations(objAnnotations)
+}
+
+// targetSecretKey returns the key of a TargetSecret the merged configuration is written to.
+func targetSecretKey(target *metalv1alpha1.TargetSecret) string {
+	return cmp.Or(target.Key, secretConfigData)
+}
+
+func (r *IgnitionV3Reconciler) patchTargetIgnitionsStatus(ctx context.Context, ignitions map[types.NamespacedName]struct{}, targetIgnition *metalv1alpha1.IgnitionV3) error {
+	// ignitions merged from other namespaces record their targets as well
 	ignitionList := &metalv1alpha1.IgnitionV3List{}
-	if err := r.List(ctx, ignitionList, &client.ListOptions{Namespace: targetIgnition.Namespace}); err != nil {
+	if err := r.List(ctx, ignitionList); err != nil {
 		return err
 	}
 
+	ref := metalv1alpha1.IgnitionReference{Namespace: targetIgnition.Namespace, Name: targetIgnition.Name}
 	for _, ignition := range ignitionList.Items {
-		if _, wasIgnitionUsedForMerging := ignitions[ignition.Name]; !wasIgnitionUsedForMerging {
+		if _, wasIgnitionUsedForMerging := ignitions[client.ObjectKeyFromObject(&ignition)]; !wasIgnitionUsedForMerging {
 			continue
 		}
-		ref := corev1.LocalObjectReference{Name: targetIgnition.Name}
-		if ignition.Name == targetIgnition.Name || slices.Contains(ignition.Status.TargetIgnitions, ref) {
+		if client.ObjectKeyFromObject(&ignition) == client.ObjectKeyFromObject(targetIgnition) || slices.Contains(ignition.Status.TargetIgnitions, ref) {
 			continue
 		}
 		ignitionBase := ignition.DeepCopy()
@@ -294,14 +959,201 @@ func (r *IgnitionV3Reconciler) patchTargetIgnitionsStatus(ctx context.Context, i
 			return err
 		}
 	}
+
+	clusterIgnitionList := &metalv1alpha1.ClusterIgnitionV3List{}
+	if err := r.List(ctx, clusterIgnitionList); err != nil {
+		return err
+	}
+
+	for _, clusterIgnition := range clusterIgnitionList.Items {
+		if _, wasIgnitionUsedForMerging := ignitions[client.ObjectKeyFromObject(&clusterIgnition)]; !wasIgnitionUsedForMerging {
+			continue
+		}
+		if slices.Contains(clusterIgnition.Status.TargetIgnitions, ref) {
+			continue
+		}
+		clusterIgnitionBase := clusterIgnition.DeepCopy()
+		clusterIgnition.Status.TargetIgnitions = append(clusterIgnition.Status.TargetIgnitions, ref)
+		if err := r.Status().Patch(ctx, &clusterIgnition, client.MergeFrom(clusterIgnitionBase)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func (r *IgnitionV3Reconciler) patchMergedIgnitionsStatus(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, order []metalv1alpha1.MergedIgnition) error {
+	if slices.Equal(ignition.Status.MergedIgnitions, order) {
+		return nil
+	}
+	ignitionBase := ignition.DeepCopy()
+	ignition.Status.MergedIgnitions = order
+	return r.Status().Patch(ctx, ignition, client.MergeFrom(ignitionBase))
+}
+
+func (r *IgnitionV3Reconciler) patchOutputStatus(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, renderedSize int, strategy string) error {
+	if ignition.Status.RenderedSize == int64(renderedSize) && ignition.Status.OutputStrategy == strategy {
+		return nil
+	}
+	ignitionBase := ignition.DeepCopy()
+	ignition.Status.RenderedSize = int64(renderedSize)
+	ignition.Status.OutputStrategy = strategy
+	return r.Status().Patch(ctx, ignition, client.MergeFrom(ignitionBase))
+}
+
+// clusterIgnitionToIgnitions maps a ClusterIgnitionV3 to the IgnitionV3 objects which have to be reconciled after it changed.
+// These are the targets recorded in its status, the targets of cluster ignitions selecting it and
+// the ignitions selecting it directly, together with their targets.
+func (r *IgnitionV3Reconciler) clusterIgnitionToIgnitions(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := ctrllog.FromContext(ctx)
+
+	clusterIgnition, ok := obj.(*metalv1alpha1.ClusterIgnitionV3)
+	if !ok {
+		return nil
+	}
+
+	ignitionList := &metalv1alpha1.IgnitionV3List{}
+	if err := r.List(ctx, ignitionList); err != nil {
+		log.Error(err, "couldn't list ignitions")
+		return nil
+	}
+	requests := ignitionsWithTargets(ignitionList.Items, func(ignition *metalv1alpha1.IgnitionV3) bool {
+		return isSelected(ignition.Spec.Ignition.Config.ClusterMerge, clusterIgnition.Labels)
+	})
+
+	clusterIgnitionList := &metalv1alpha1.ClusterIgnitionV3List{}
+	if err := r.List(ctx, clusterIgnitionList); err != nil {
+		log.Error(err, "couldn't list cluster ignitions")
+		return nil
+	}
+	targets := clusterIgnition.Status.TargetIgnitions
+	for _, parent := range clusterIgnitionList.Items {
+		if isSelected(parent.Spec.Ignition.Config.ClusterMerge, clusterIgnition.Labels) {
+			targets = append(targets, parent.Status.TargetIgnitions...)
+		}
+	}
+	for _, ref := range targets {
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}}
+		if !slices.Contains(requests, request) {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// grantToIgnitions maps an IgnitionV3Grant or a Namespace to the IgnitionV3 objects which merge ignitions from
+// other namespaces and to their targets, as a changed grant or namespace label can change what they merge.
+func (r *IgnitionV3Reconciler) grantToIgnitions(ctx context.Context, _ client.Object) []reconcile.Request {
+	ignitionList := &metalv1alpha1.IgnitionV3List{}
+	if err := r.List(ctx, ignitionList); err != nil {
+		ctrllog.FromContext(ctx).Error(err, "couldn't list ignitions")
+		return nil
+	}
+	return ignitionsWithTargets(ignitionList.Items, func(ignition *metalv1alpha1.IgnitionV3) bool {
+		return ignition.Spec.Ignition.Config.NamespaceSelector != nil
+	})
+}
+
+// ignitionToReferencingIgnitions maps an IgnitionV3 to the IgnitionV3 objects which reference it in mergeRefs
+// and to their targets.
+func (r *IgnitionV3Reconciler) ignitionToReferencingIgnitions(ctx context.Context, obj client.Object) []reconcile.Request {
+	ignitionList := &metalv1alpha1.IgnitionV3List{}
+	if err := r.List(ctx, ignitionList, &client.ListOptions{Namespace: obj.GetNamespace()}); err != nil {
+		ctrllog.FromContext(ctx).Error(err, "couldn't list ignitions")
+		return nil
+	}
+	return ignitionsWithTargets(ignitionList.Items, func(ignition *metalv1alpha1.IgnitionV3) bool {
+		return slices.Contains(ignition.Spec.Ignition.Config.MergeRefs, corev1.LocalObjectReference{Name: obj.GetName()})
+	})
+}
+
+// ignitionToCrossNamespaceIgnitions maps an IgnitionV3 to the IgnitionV3 objects of other namespaces
+// which select it with namespaceSelector and to their targets.
+func (r *IgnitionV3Reconciler) ignitionToCrossNamespaceIgnitions(ctx context.Context, obj client.Object) []reconcile.Request {
+	log := ctrllog.FromContext(ctx)
+
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: obj.GetNamespace()}, namespace); err != nil {
+		log.Error(err, "couldn't get namespace")
+		return nil
+	}
+
+	ignitionList := &metalv1alpha1.IgnitionV3List{}
+	if err := r.List(ctx, ignitionList); err != nil {
+		log.Error(err, "couldn't list ignitions")
+		return nil
+	}
+	return ignitionsWithTargets(ignitionList.Items, func(ignition *metalv1alpha1.IgnitionV3) bool {
+		cfg := ignition.Spec.Ignition.Config
+		if ignition.Namespace == obj.GetNamespace() || cfg.Merge == nil || cfg.NamespaceSelector == nil {
+			return false
+		}
+		return isSelected(cfg.NamespaceSelector, namespace.Labels) && isSelected(cfg.Merge, obj.GetLabels())
+	})
+}
+
+// configSourceToIgnitions maps a Secret or a ConfigMap to the IgnitionV3 objects which read it
+// and to their targets.
+func (r *IgnitionV3Reconciler) configSourceToIgnitions(ctx context.Context, obj client.Object) []reconcile.Request {
+	kind := metalv1alpha1.ConfigMapKind
+	if _, ok := obj.(*corev1.Secret); ok {
+		kind = metalv1alpha1.SecretKind
+	}
+
+	ignitionList := &metalv1alpha1.IgnitionV3List{}
+	if err := r.List(ctx, ignitionList, &client.ListOptions{Namespace: obj.GetNamespace()}); err != nil {
+		ctrllog.FromContext(ctx).Error(err, "couldn't list ignitions")
+		return nil
+	}
+	return ignitionsWithTargets(ignitionList.Items, func(ignition *metalv1alpha1.IgnitionV3) bool {
+		return slices.ContainsFunc(configSources(ignition), func(source metalv1alpha1.ConfigSource) bool {
+			ref := newConfigSourceRef(ignition.Namespace, source)
+			return ref.kind == kind && ref.nn.Name == obj.GetName()
+		})
+	})
+}
+
+// ignitionsWithTargets returns requests for the ignitions matching the filter and for their targets.
+func ignitionsWithTargets(ignitions []metalv1alpha1.IgnitionV3, filter func(*metalv1alpha1.IgnitionV3) bool) []reconcile.Request {
+	requests := map[types.NamespacedName]struct{}{}
+	for _, ignition := range ignitions {
+		if !filter(&ignition) {
+			continue
+		}
+		requests[client.ObjectKeyFromObject(&ignition)] = struct{}{}
+		for _, ref := range ignition.Status.TargetIgnitions {
+			requests[types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}] = struct{}{}
+		}
+	}
+
+	result := make([]reconcile.Request, 0, len(requests))
+	for nn := range requests {
+		result = append(result, reconcile.Request{NamespacedName: nn})
+	}
+	return result
+}
+
+func isSelected(selector *metav1.LabelSelector, objLabels map[string]string) bool {
+	if selector == nil {
+		return false
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	return err == nil && s.Matches(labels.Set(objLabels))
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *IgnitionV3Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&metalv1alpha1.IgnitionV3{}).
 		Owns(&corev1.Secret{}).
+		Owns(&corev1.ConfigMap{}).
+		Watches(&metalv1alpha1.IgnitionV3{}, handler.EnqueueRequestsFromMapFunc(r.ignitionToReferencingIgnitions)).
+		Watches(&metalv1alpha1.IgnitionV3{}, handler.EnqueueRequestsFromMapFunc(r.ignitionToCrossNamespaceIgnitions)).
+		Watches(&metalv1alpha1.ClusterIgnitionV3{}, handler.EnqueueRequestsFromMapFunc(r.clusterIgnitionToIgnitions)).
+		Watches(&metalv1alpha1.IgnitionV3Grant{}, handler.EnqueueRequestsFromMapFunc(r.grantToIgnitions)).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.grantToIgnitions),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.configSourceToIgnitions)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.configSourceToIgnitions)).
 		Named("ignitionv3").
 		Complete(r)
 }
@@ -4,13 +4,24 @@
 package controller
 
 import (
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+
 	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
+	ignitionConfig "github.com/coreos/ignition/v2/config/v3_5"
+	ignitiontypes "github.com/coreos/ignition/v2/config/v3_5/types"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -64,6 +75,71 @@ var _ = Describe("IgnitionV3 Controller", func() {
 				Expect(meta.IsStatusConditionTrue(ign.Status.Conditions, metalv1alpha1.ConfigurationType)).To(BeTrue())
 			})
 
+			It("when configuration uses fields unsupported by its version, should update status", func() {
+				ign.Spec.Ignition.Version = "3.2.0"
+				ign.Spec.KernelArguments.ShouldExist = []metalv1alpha1.KernelArgument{"console=ttyS0"}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConfigurationType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+				Expect(condition.Message).To(Equal("ignition version 3.2.0 doesn't support $.kernelArguments"))
+			})
+
+			It("when butane config has warnings, should update status with the warnings", func() {
+				ign.Spec.Ignition.Version = validConfigVersion
+				ign.Spec.Butane = "variant: fcos\nversion: 1.6.0\nunknown: value\n"
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConfigurationType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+				Expect(condition.Reason).To(Equal("ConversionSucceededWithWarnings"))
+				Expect(condition.Message).To(ContainSubstring("Unused key unknown"))
+			})
+
+			It("when butane config uses local files, should update status", func() {
+				ign.Spec.Ignition.Version = validConfigVersion
+				ign.Spec.Butane = "variant: fcos\nversion: 1.6.0\nstorage:\n  files:\n    - path: /etc/motd\n      contents:\n        local: motd\n"
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConfigurationType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+				Expect(condition.Message).To(ContainSubstring("couldn't translate butane config"))
+			})
+
+			It("when raw config is invalid, should update status", func() {
+				ign.Spec.Ignition.Version = validConfigVersion
+				ign.Spec.Raw = &runtime.RawExtension{Raw: []byte(`{"ignition":{"version":"invalid"}}`)}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConfigurationType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+				Expect(condition.Message).To(ContainSubstring("couldn't parse raw config"))
+			})
+
 			It("when configuration is invalid, should update status", func() {
 				ign.Spec.Ignition.Version = "invalid"
 				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
@@ -85,16 +161,17 @@ var _ = Describe("IgnitionV3 Controller", func() {
 			)
 
 			var (
-				secret   *corev1.Secret
-				secretNn = types.NamespacedName{Name: secretName, Namespace: namespace}
-				ign2     *metalv1alpha1.IgnitionV3
-				ign3     *metalv1alpha1.IgnitionV3
+				secret       *corev1.Secret
+				secretNn     = types.NamespacedName{Name: secretName, Namespace: namespace}
+				provenanceNn = types.NamespacedName{Name: name + metalv1alpha1.ProvenanceConfigMapSuffix, Namespace: namespace}
+				ign2         *metalv1alpha1.IgnitionV3
+				ign3         *metalv1alpha1.IgnitionV3
 			)
 
 			BeforeEach(func() {
 				ign.Spec.Ignition.Version = validConfigVersion
 				ign.Spec.KernelArguments.ShouldExist = []metalv1alpha1.KernelArgument{"ignition-1 value"}
-				ign.Spec.TargetSecret = &corev1.LocalObjectReference{Name: secretName}
+				ign.Spec.TargetSecret = &metalv1alpha1.TargetSecret{Name: secretName}
 				labels := map[string]string{"merge": "true"}
 				ign.Spec.Ignition.Config.Merge = &metav1.LabelSelector{MatchLabels: labels} // link to ign2
 
@@ -115,6 +192,7 @@ var _ = Describe("IgnitionV3 Controller", func() {
 
 			AfterEach(func() {
 				deleteIfPresent(secret)
+				deleteIfPresent(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: provenanceNn.Name, Namespace: namespace}})
 				deleteIfPresent(ign2, withFinalizers)
 				deleteIfPresent(ign3, withFinalizers)
 			})
@@ -130,6 +208,224 @@ var _ = Describe("IgnitionV3 Controller", func() {
 				Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["ignition-1 value"]},"passwd":{},"storage":{},"systemd":{}}`)))
 			})
 
+			It("when target secret has a key, a type, extra data, labels and annotations, should create a secret with them", func() {
+				ign.Spec.TargetSecret.Key = "value"
+				ign.Spec.TargetSecret.Type = "cluster.x-k8s.io/secret"
+				ign.Spec.TargetSecret.ExtraData = map[string]string{"format": "ignition"}
+				ign.Spec.TargetSecret.Labels = map[string]string{"consumer": "capi"}
+				ign.Spec.TargetSecret.Annotations = map[string]string{"description": "bootstrap data"}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				Expect(secret.Type).To(Equal(corev1.SecretType("cluster.x-k8s.io/secret")))
+				Expect(secret.Data).To(HaveKey("value"))
+				Expect(secret.Data).NotTo(HaveKey(secretConfigData))
+				Expect(secret.Data).To(HaveKeyWithValue("format", []byte("ignition")))
+				Expect(secret.Labels).To(HaveKeyWithValue("consumer", "capi"))
+				Expect(secret.Annotations).To(HaveKeyWithValue("description", "bootstrap data"))
+			})
+
+			It("when extra data and labels are removed from the target secret, should remove them and keep data written by others", func() {
+				ign.Spec.TargetSecret.ExtraData = map[string]string{"format": "ignition"}
+				ign.Spec.TargetSecret.Labels = map[string]string{"consumer": "capi"}
+				secret.Data = map[string][]byte{"token": []byte("value")}
+				Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				ign.Spec.TargetSecret.ExtraData = nil
+				ign.Spec.TargetSecret.Labels = nil
+				Expect(k8sClient.Update(ctx, ign)).To(Succeed())
+				_, err = controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				Expect(secret.Data).To(HaveKey(secretConfigData))
+				Expect(secret.Data).To(HaveKeyWithValue("token", []byte("value")))
+				Expect(secret.Data).NotTo(HaveKey("format"))
+				Expect(secret.Labels).NotTo(HaveKey("consumer"))
+			})
+
+			It("when an existing target secret without recorded data keys gets another key, should remove the default key and keep data written by others", func() {
+				ign.Spec.TargetSecret.Key = "value"
+				secret.Data = map[string][]byte{secretConfigData: []byte("{}"), "token": []byte("value")}
+				Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				Expect(secret.Data).To(HaveKey("value"))
+				Expect(secret.Data).NotTo(HaveKey(secretConfigData))
+				Expect(secret.Data).To(HaveKeyWithValue("token", []byte("value")))
+			})
+
+			It("when target secret has extra data with its key, should be rejected", func() {
+				ign.Spec.TargetSecret.ExtraData = map[string]string{secretConfigData: "value"}
+				Expect(k8sClient.Create(ctx, ign)).NotTo(Succeed())
+			})
+
+			It("when the name of the target secret changes, should be rejected", func() {
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				ign.Spec.TargetSecret.Name = "other-secret"
+				Expect(k8sClient.Update(ctx, ign)).NotTo(Succeed())
+			})
+
+			It("when outputs are set, should write the merged config to each of them and update their status", func() {
+				outputSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "test-output-secret", Namespace: namespace}}
+				outputConfigMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test-output-configmap", Namespace: namespace}}
+				DeferCleanup(func() {
+					deleteIfPresent(outputSecret)
+					deleteIfPresent(outputConfigMap)
+				})
+				ign.Spec.Outputs = []metalv1alpha1.IgnitionV3Output{
+					{Name: "secret", Secret: &metalv1alpha1.TargetSecret{Name: outputSecret.Name, Key: "userData"}, OutputVersion: "3.4.0"},
+					{Name: "configmap", ConfigMap: &metalv1alpha1.TargetConfigMap{Name: outputConfigMap.Name, ExtraData: map[string]string{"format": "ignition"}}},
+					{Name: "legacy", Secret: &metalv1alpha1.TargetSecret{Name: "test-output-legacy"}, OutputVersion: "3.2.0"},
+				}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				config := `{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["ignition-1 value"]},"passwd":{},"storage":{},"systemd":{}}`
+				Expect(secret.Data[secretConfigData]).To(Equal([]byte(config)))
+
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(outputSecret), outputSecret)).To(Succeed())
+				Expect(outputSecret.Data["userData"]).To(ContainSubstring(`"version":"3.4.0"`))
+				Expect(outputSecret.Data["userData"]).To(ContainSubstring(`"kernelArguments":{"shouldExist":["ignition-1 value"]}`))
+
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(outputConfigMap), outputConfigMap)).To(Succeed())
+				Expect(outputConfigMap.Data).To(HaveKeyWithValue(secretConfigData, config))
+				Expect(outputConfigMap.Data).To(HaveKeyWithValue("format", "ignition"))
+
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "test-output-legacy", Namespace: namespace}, &corev1.Secret{})).NotTo(Succeed())
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				Expect(ign.Status.Outputs).To(HaveLen(3))
+				Expect(ign.Status.Outputs[0]).To(Equal(metalv1alpha1.OutputStatus{
+					Name: "secret", Ready: true, RenderedSize: int64(len(outputSecret.Data["userData"])), OutputStrategy: metalv1alpha1.OutputStrategyPlain,
+				}))
+				Expect(ign.Status.Outputs[1]).To(Equal(metalv1alpha1.OutputStatus{
+					Name: "configmap", Ready: true, RenderedSize: int64(len(config)), OutputStrategy: metalv1alpha1.OutputStrategyPlain,
+				}))
+				Expect(ign.Status.Outputs[2].Ready).To(BeFalse())
+				Expect(ign.Status.Outputs[2].Message).To(ContainSubstring("kernelArguments"))
+			})
+
+			It("when an output is removed, should delete its object if owned by the ignition", func() {
+				outputSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "test-output-secret", Namespace: namespace}}
+				outputConfigMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test-output-configmap", Namespace: namespace}}
+				otherSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "test-other-secret", Namespace: namespace}}
+				DeferCleanup(func() {
+					deleteIfPresent(outputSecret)
+					deleteIfPresent(outputConfigMap)
+					deleteIfPresent(otherSecret)
+				})
+				ign.Spec.Outputs = []metalv1alpha1.IgnitionV3Output{
+					{Name: "secret", Secret: &metalv1alpha1.TargetSecret{Name: outputSecret.Name}},
+					{Name: "configmap", ConfigMap: &metalv1alpha1.TargetConfigMap{Name: outputConfigMap.Name}},
+				}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, otherSecret)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(outputSecret), outputSecret)).To(Succeed())
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(outputConfigMap), outputConfigMap)).To(Succeed())
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				ign.Spec.Outputs = ign.Spec.Outputs[1:]
+				Expect(k8sClient.Update(ctx, ign)).To(Succeed())
+				_, err = controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(outputSecret), outputSecret)).NotTo(Succeed())
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(outputConfigMap), outputConfigMap)).To(Succeed())
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(otherSecret), otherSecret)).To(Succeed())
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+			})
+
+			It("when the object of an output exists without being owned by the ignition, should keep it and update the output status", func() {
+				outputSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "test-output-secret", Namespace: namespace}}
+				outputSecret.Data = map[string][]byte{"other": []byte("value")}
+				DeferCleanup(func() {
+					deleteIfPresent(outputSecret)
+				})
+				Expect(k8sClient.Create(ctx, outputSecret)).To(Succeed())
+				ign.Spec.Outputs = []metalv1alpha1.IgnitionV3Output{{Name: "secret", Secret: &metalv1alpha1.TargetSecret{Name: outputSecret.Name}}}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(outputSecret), outputSecret)).To(Succeed())
+				Expect(outputSecret.Data).To(Equal(map[string][]byte{"other": []byte("value")}))
+				Expect(outputSecret.OwnerReferences).To(BeEmpty())
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				Expect(ign.Status.Outputs).To(HaveLen(1))
+				Expect(ign.Status.Outputs[0].Ready).To(BeFalse())
+				Expect(ign.Status.Outputs[0].Message).To(ContainSubstring("isn't owned by the ignition"))
+
+				ign.Spec.Outputs = nil
+				Expect(k8sClient.Update(ctx, ign)).To(Succeed())
+				_, err = controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(outputSecret), outputSecret)).To(Succeed())
+			})
+
+			It("when outputs use the same object, should be rejected", func() {
+				ign.Spec.Outputs = []metalv1alpha1.IgnitionV3Output{
+					{Name: "first", Secret: &metalv1alpha1.TargetSecret{Name: "test-output-secret"}},
+					{Name: "second", Secret: &metalv1alpha1.TargetSecret{Name: "test-output-secret"}},
+				}
+				Expect(k8sClient.Create(ctx, ign)).NotTo(Succeed())
+
+				ign.Spec.Outputs = []metalv1alpha1.IgnitionV3Output{
+					{Name: "first", ConfigMap: &metalv1alpha1.TargetConfigMap{Name: "test-output"}},
+					{Name: "second", ConfigMap: &metalv1alpha1.TargetConfigMap{Name: "test-output"}},
+				}
+				Expect(k8sClient.Create(ctx, ign)).NotTo(Succeed())
+			})
+
+			It("when an output uses the target, rendered or provenance object, should be rejected", func() {
+				ign.Spec.Outputs = []metalv1alpha1.IgnitionV3Output{{Name: "target", Secret: &metalv1alpha1.TargetSecret{Name: secretName}}}
+				Expect(k8sClient.Create(ctx, ign)).NotTo(Succeed())
+
+				ign.Spec.Outputs = []metalv1alpha1.IgnitionV3Output{{Name: "rendered", Secret: &metalv1alpha1.TargetSecret{Name: name + metalv1alpha1.RenderedSecretSuffix}}}
+				Expect(k8sClient.Create(ctx, ign)).NotTo(Succeed())
+
+				ign.Spec.Outputs = []metalv1alpha1.IgnitionV3Output{{Name: "provenance", ConfigMap: &metalv1alpha1.TargetConfigMap{Name: name + metalv1alpha1.ProvenanceConfigMapSuffix}}}
+				Expect(k8sClient.Create(ctx, ign)).NotTo(Succeed())
+
+				ign.Spec.Outputs = []metalv1alpha1.IgnitionV3Output{{Name: "secret", Secret: &metalv1alpha1.TargetSecret{Name: name + metalv1alpha1.ProvenanceConfigMapSuffix}}}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+			})
+
+			It("when an output sets both a secret and a config map, should be rejected", func() {
+				ign.Spec.Outputs = []metalv1alpha1.IgnitionV3Output{{
+					Name:      "both",
+					Secret:    &metalv1alpha1.TargetSecret{Name: "test-output-secret"},
+					ConfigMap: &metalv1alpha1.TargetConfigMap{Name: "test-output-configmap"},
+				}}
+				Expect(k8sClient.Create(ctx, ign)).NotTo(Succeed())
+			})
+
 			It("when merge is not empty, should create a secret with merged config", func() {
 				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
 				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
@@ -155,79 +451,1252 @@ var _ = Describe("IgnitionV3 Controller", func() {
 				Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["ignition-1 value"],"shouldNotExist":["ignition-2 value"]},"passwd":{"groups":[{"name":"ignition-3 value"}]},"storage":{},"systemd":{}}`)))
 			})
 
-			When("Ignition has replace field", func() {
-				const (
-					replaceName = "test-ignition-replace"
-				)
+			It("when merged IgnitionV3 have priorities, should merge them ordered by priority", func() {
+				ign2.Spec.Priority = 10
+				ign2.Spec.Passwd.Groups = []metalv1alpha1.PasswdGroup{{Name: "ignition-3 value", Gid: ptr.To(2)}}
+				ign3.Labels = ign2.Labels
+				ign3.Spec.Passwd.Groups[0].Gid = ptr.To(3)
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign3)).To(Succeed())
 
-				var (
-					replaceIgn *metalv1alpha1.IgnitionV3
-				)
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
 
-				BeforeEach(func() {
-					replaceIgn = &metalv1alpha1.IgnitionV3{ObjectMeta: metav1.ObjectMeta{Name: replaceName, Namespace: namespace}}
-					replaceIgn.Spec.Ignition.Version = validConfigVersion
-					replaceIgn.Spec.KernelArguments.ShouldExist = []metalv1alpha1.KernelArgument{"replace ignition value"}
-					replaceIgn.Spec.Passwd.Groups = []metalv1alpha1.PasswdGroup{{Name: "replace ignition value"}}
-				})
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["ignition-1 value"],"shouldNotExist":["ignition-2 value"]},"passwd":{"groups":[{"gid":2,"name":"ignition-3 value"}]},"storage":{},"systemd":{}}`)))
 
-				AfterEach(func() {
-					deleteIfPresent(replaceIgn, withFinalizers)
-				})
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				Expect(ign.Status.MergedIgnitions).To(Equal([]metalv1alpha1.MergedIgnition{
+					{Kind: metalv1alpha1.IgnitionV3Kind, Namespace: namespace, Name: name, Version: validConfigVersion, Keys: 1},
+					{Kind: metalv1alpha1.IgnitionV3Kind, Namespace: namespace, Name: name3, Version: validConfigVersion},
+					{Kind: metalv1alpha1.IgnitionV3Kind, Namespace: namespace, Name: name2, Priority: 10, Version: validConfigVersion, Keys: 1},
+				}))
+			})
 
-				It("when an IgnitionV3 has a replace loop, should update the IgnitionV3 status to false", func() {
-					replaceIgn.Spec.Ignition.Config.Replace = &corev1.LocalObjectReference{Name: replaceName}
-					Expect(k8sClient.Create(ctx, replaceIgn)).To(Succeed())
+			It("when merged IgnitionV3 define a file with differing content, should update the conflicts condition to true", func() {
+				ign2.Spec.Storage.Files = []metalv1alpha1.File{{Node: metalv1alpha1.Node{Path: "/etc/chrony.conf"}, FileEmbedded1: metalv1alpha1.FileEmbedded1{Mode: ptr.To(0644)}}}
+				ign3.Spec.Storage.Files = []metalv1alpha1.File{{Node: metalv1alpha1.Node{Path: "/etc/chrony.conf"}, FileEmbedded1: metalv1alpha1.FileEmbedded1{Mode: ptr.To(0600)}}}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign3)).To(Succeed())
 
-					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
-					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
-					Expect(err).NotTo(HaveOccurred())
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
 
-					Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(replaceIgn), replaceIgn)).To(Succeed())
-					Expect(meta.IsStatusConditionTrue(replaceIgn.Status.Conditions, metalv1alpha1.ConfigurationType)).To(BeFalse())
-				})
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConflictsType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+				Expect(condition.Message).To(Equal("file /etc/chrony.conf defined by IgnitionV3/test-namespace/test-ignition-2, IgnitionV3/test-namespace/test-ignition-3"))
+			})
 
-				It("when an IgnitionV3 with target secret is replaced with non existing IgnitionV3, should return an error", func() {
-					ign.Spec.Ignition.Config.Replace = &corev1.LocalObjectReference{Name: replaceName}
-					ign.Spec.Ignition.Config.Merge = nil
-					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+			It("when merged IgnitionV3 conflict and the conflict policy is Fail, should update the IgnitionV3 status to false and not create a secret", func() {
+				ign.Spec.ConflictPolicy = metalv1alpha1.ConflictPolicyFail
+				ign2.Spec.Storage.Files = []metalv1alpha1.File{{Node: metalv1alpha1.Node{Path: "/etc/chrony.conf"}, FileEmbedded1: metalv1alpha1.FileEmbedded1{Mode: ptr.To(0644)}}}
+				ign3.Spec.Storage.Files = []metalv1alpha1.File{{Node: metalv1alpha1.Node{Path: "/etc/chrony.conf"}, FileEmbedded1: metalv1alpha1.FileEmbedded1{Mode: ptr.To(0600)}}}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign3)).To(Succeed())
 
-					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
-					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
-					Expect(err.Error()).To(Equal(`couldn't create merged configuration: couldn't get ignition. Reason: ignitionv3s.metal.cobaltcore.dev "test-ignition-replace" not found`))
-				})
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
 
-				It("when an IgnitionV3 with target secret is replaced with existing IgnitionV3, should create a secret with replaced config", func() {
-					ign.Spec.Ignition.Config.Replace = &corev1.LocalObjectReference{Name: replaceName}
-					ign.Spec.Ignition.Config.Merge = nil
-					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
-					Expect(k8sClient.Create(ctx, replaceIgn)).To(Succeed())
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConfigurationType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+				Expect(condition.Reason).To(Equal("MergeConflicts"))
+				Expect(meta.IsStatusConditionTrue(ign.Status.Conditions, metalv1alpha1.ConflictsType)).To(BeTrue())
+				Expect(k8sClient.Get(ctx, secretNn, secret)).NotTo(Succeed())
+			})
 
-					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
-					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
-					Expect(err).NotTo(HaveOccurred())
+			It("when merged IgnitionV3 require and forbid the same kernel argument, should create a secret with the argument of the one merged last and report the conflict", func() {
+				ign2.Spec.KernelArguments.ShouldExist = []metalv1alpha1.KernelArgument{"console=ttyS0"}
+				ign3.Spec.KernelArguments.ShouldNotExist = []metalv1alpha1.KernelArgument{"console=ttyS0"}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign3)).To(Succeed())
 
-					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
-					Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["replace ignition value"]},"passwd":{"groups":[{"name":"replace ignition value"}]},"storage":{},"systemd":{}}`)))
-				})
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
 
-				It("when an IgnitionV3 collected with merge is replaced with existing IgnitionV3, should create a secret with replaced config", func() {
-					ign3.Spec.Ignition.Config.Replace = &corev1.LocalObjectReference{Name: replaceName}
-					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
-					Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
-					Expect(k8sClient.Create(ctx, ign3)).To(Succeed())
-					Expect(k8sClient.Create(ctx, replaceIgn)).To(Succeed())
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				config, _, err := ignitionConfig.Parse(secret.Data[secretConfigData])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.KernelArguments.ShouldExist).NotTo(ContainElement(ignitiontypes.KernelArgument("console=ttyS0")))
+				Expect(config.KernelArguments.ShouldNotExist).To(ContainElement(ignitiontypes.KernelArgument("console=ttyS0")))
 
-					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
-					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ign3)})
-					Expect(err).NotTo(HaveOccurred())
-					_, err = controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
-					Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConflictsType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+				Expect(condition.Reason).To(Equal("ConflictsDetected"))
+				Expect(condition.Message).To(Equal("kernel argument console=ttyS0 defined by IgnitionV3/test-namespace/test-ignition-2, IgnitionV3/test-namespace/test-ignition-3"))
 
-					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
-					Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["ignition-1 value","replace ignition value"],"shouldNotExist":["ignition-2 value"]},"passwd":{"groups":[{"name":"replace ignition value"}]},"storage":{},"systemd":{}}`)))
-				})
+				condition = meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConfigurationType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+				Expect(condition.Reason).To(Equal("KernelArgumentsContradictionsMerged"))
+				Expect(condition.Message).To(Equal("Kernel arguments required and forbidden by different merged objects were merged, the object merged last wins: console=ttyS0 (IgnitionV3/test-namespace/test-ignition-2, IgnitionV3/test-namespace/test-ignition-3)"))
 			})
-		})
+
+			It("when merged IgnitionV3 require and forbid the same kernel argument and the conflict policy is FirstWins, should create a secret with the argument of the one merged first", func() {
+				ign.Spec.ConflictPolicy = metalv1alpha1.ConflictPolicyFirstWins
+				ign2.Spec.KernelArguments.ShouldExist = []metalv1alpha1.KernelArgument{"console=ttyS0"}
+				ign3.Spec.KernelArguments.ShouldNotExist = []metalv1alpha1.KernelArgument{"console=ttyS0"}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign3)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				config, _, err := ignitionConfig.Parse(secret.Data[secretConfigData])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.KernelArguments.ShouldExist).To(ContainElement(ignitiontypes.KernelArgument("console=ttyS0")))
+				Expect(config.KernelArguments.ShouldNotExist).NotTo(ContainElement(ignitiontypes.KernelArgument("console=ttyS0")))
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConflictsType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Reason).To(Equal("ConflictsResolved"))
+			})
+
+			It("when merged IgnitionV3 require and forbid the same kernel argument and the conflict policy is Fail, should update the IgnitionV3 status to false and not create a secret", func() {
+				ign.Spec.ConflictPolicy = metalv1alpha1.ConflictPolicyFail
+				ign2.Spec.KernelArguments.ShouldExist = []metalv1alpha1.KernelArgument{"console=ttyS0"}
+				ign3.Spec.KernelArguments.ShouldNotExist = []metalv1alpha1.KernelArgument{"console=ttyS0"}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign3)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConfigurationType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+				Expect(condition.Reason).To(Equal("MergeConflicts"))
+				Expect(k8sClient.Get(ctx, secretNn, secret)).NotTo(Succeed())
+			})
+
+			It("when merged IgnitionV3 conflict and the conflict policy is FirstWins, should create a secret with the definition merged first", func() {
+				ign.Spec.ConflictPolicy = metalv1alpha1.ConflictPolicyFirstWins
+				ign.Spec.KernelArguments.ShouldExist = nil
+				ign2.Spec.KernelArguments.ShouldNotExist = nil
+				ign2.Spec.Passwd.Groups = []metalv1alpha1.PasswdGroup{{Name: "ignition-3 value", Gid: ptr.To(2)}}
+				ign3.Spec.Passwd.Groups = []metalv1alpha1.PasswdGroup{{Name: "ignition-3 value", PasswordHash: ptr.To("hash")}}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign3)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{},"passwd":{"groups":[{"gid":2,"name":"ignition-3 value"}]},"storage":{},"systemd":{}}`)))
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConflictsType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+				Expect(condition.Reason).To(Equal("ConflictsResolved"))
+			})
+
+			It("when merged IgnitionV3 only differ in appends, SSH keys and groups, should merge them without conflicts", func() {
+				ign2.Spec.Storage.Files = []metalv1alpha1.File{{Node: metalv1alpha1.Node{Path: "/etc/motd"}, FileEmbedded1: metalv1alpha1.FileEmbedded1{Append: []metalv1alpha1.Resource{{Source: ptr.To("data:,a")}}}}}
+				ign2.Spec.Passwd.Users = []metalv1alpha1.PasswdUser{{Name: "core", SSHAuthorizedKeys: []metalv1alpha1.SSHAuthorizedKey{"ssh-ed25519 a"}, Groups: []metalv1alpha1.Group{"wheel"}}}
+				ign3.Spec.Storage.Files = []metalv1alpha1.File{{Node: metalv1alpha1.Node{Path: "/etc/motd"}, FileEmbedded1: metalv1alpha1.FileEmbedded1{Append: []metalv1alpha1.Resource{{Source: ptr.To("data:,b")}}}}}
+				ign3.Spec.Passwd.Users = []metalv1alpha1.PasswdUser{{Name: "core", SSHAuthorizedKeys: []metalv1alpha1.SSHAuthorizedKey{"ssh-ed25519 b"}, Groups: []metalv1alpha1.Group{"docker"}}}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign3)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				config, _, err := ignitionConfig.Parse(secret.Data[secretConfigData])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Storage.Files).To(HaveLen(1))
+				Expect(config.Storage.Files[0].Append).To(HaveLen(2))
+				Expect(config.Passwd.Users).To(HaveLen(1))
+				Expect(config.Passwd.Users[0].SSHAuthorizedKeys).To(Equal([]ignitiontypes.SSHAuthorizedKey{"ssh-ed25519 a", "ssh-ed25519 b"}))
+				Expect(config.Passwd.Users[0].Groups).To(Equal([]ignitiontypes.Group{"wheel", "docker"}))
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				Expect(meta.IsStatusConditionFalse(ign.Status.Conditions, metalv1alpha1.ConflictsType)).To(BeTrue())
+			})
+
+			It("when merged IgnitionV3 conflict and the conflict policy is LastWins, should keep the appends, SSH keys and groups of the definition merged first", func() {
+				ign.Spec.ConflictPolicy = metalv1alpha1.ConflictPolicyLastWins
+				ign2.Spec.Storage.Files = []metalv1alpha1.File{{Node: metalv1alpha1.Node{Path: "/etc/motd"}, FileEmbedded1: metalv1alpha1.FileEmbedded1{Mode: ptr.To(0644), Append: []metalv1alpha1.Resource{{Source: ptr.To("data:,a")}}}}}
+				ign2.Spec.Passwd.Users = []metalv1alpha1.PasswdUser{{Name: "core", Shell: ptr.To("/bin/sh"), SSHAuthorizedKeys: []metalv1alpha1.SSHAuthorizedKey{"ssh-ed25519 a"}}}
+				ign3.Spec.Storage.Files = []metalv1alpha1.File{{Node: metalv1alpha1.Node{Path: "/etc/motd"}, FileEmbedded1: metalv1alpha1.FileEmbedded1{Mode: ptr.To(0600), Append: []metalv1alpha1.Resource{{Source: ptr.To("data:,b")}}}}}
+				ign3.Spec.Passwd.Users = []metalv1alpha1.PasswdUser{{Name: "core", Shell: ptr.To("/bin/bash"), SSHAuthorizedKeys: []metalv1alpha1.SSHAuthorizedKey{"ssh-ed25519 b"}}}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign3)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				config, _, err := ignitionConfig.Parse(secret.Data[secretConfigData])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Storage.Files).To(HaveLen(1))
+				Expect(config.Storage.Files[0].Mode).To(Equal(ptr.To(0600)))
+				Expect(config.Storage.Files[0].Append).To(HaveLen(2))
+				Expect(config.Passwd.Users).To(HaveLen(1))
+				Expect(config.Passwd.Users[0].Shell).To(Equal(ptr.To("/bin/bash")))
+				Expect(config.Passwd.Users[0].SSHAuthorizedKeys).To(Equal([]ignitiontypes.SSHAuthorizedKey{"ssh-ed25519 a", "ssh-ed25519 b"}))
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConflictsType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Message).To(Equal("file /etc/motd defined by IgnitionV3/test-namespace/test-ignition-2, IgnitionV3/test-namespace/test-ignition-3; " +
+					"user core defined by IgnitionV3/test-namespace/test-ignition-2, IgnitionV3/test-namespace/test-ignition-3"))
+			})
+
+			It("when the merge strategy is Aggregate, should create a secret with the dropins and appends of all merged IgnitionV3", func() {
+				ign.Spec.ConflictPolicy = metalv1alpha1.ConflictPolicyFirstWins
+				ign.Spec.MergeStrategy = metalv1alpha1.MergeStrategyAggregate
+				ign2.Spec.Systemd.Units = []metalv1alpha1.Unit{{Name: "kubelet.service", Enabled: ptr.To(true), Dropins: []metalv1alpha1.Dropin{{Name: "10-a.conf", Contents: ptr.To("a")}}}}
+				ign2.Spec.Storage.Files = []metalv1alpha1.File{{Node: metalv1alpha1.Node{Path: "/etc/motd"}, FileEmbedded1: metalv1alpha1.FileEmbedded1{Append: []metalv1alpha1.Resource{{Source: ptr.To("data:,a")}}}}}
+				ign3.Spec.Systemd.Units = []metalv1alpha1.Unit{{Name: "kubelet.service", Enabled: ptr.To(false), Dropins: []metalv1alpha1.Dropin{{Name: "20-b.conf", Contents: ptr.To("b")}}}}
+				ign3.Spec.Storage.Files = []metalv1alpha1.File{{Node: metalv1alpha1.Node{Path: "/etc/motd"}, FileEmbedded1: metalv1alpha1.FileEmbedded1{Append: []metalv1alpha1.Resource{{Source: ptr.To("data:,b")}, {Source: ptr.To("data:,a")}}}}}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign3)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				config, _, err := ignitionConfig.Parse(secret.Data[secretConfigData])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Systemd.Units).To(HaveLen(1))
+				Expect(config.Systemd.Units[0].Enabled).To(Equal(ptr.To(true)))
+				Expect(config.Systemd.Units[0].Dropins).To(HaveLen(2))
+				Expect(config.Storage.Files).To(HaveLen(1))
+				Expect(config.Storage.Files[0].Append).To(HaveLen(2))
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConflictsType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Message).To(Equal("unit kubelet.service defined by IgnitionV3/test-namespace/test-ignition-2, IgnitionV3/test-namespace/test-ignition-3"))
+			})
+
+			It("when the conflict policy is set without a target secret, should be rejected", func() {
+				ign.Spec.TargetSecret = nil
+				ign.Spec.ConflictPolicy = metalv1alpha1.ConflictPolicyFail
+				Expect(k8sClient.Create(ctx, ign)).NotTo(Succeed())
+			})
+
+			It("when merged IgnitionV3 define a file with the same content, should update the conflicts condition to false", func() {
+				ign2.Spec.Storage.Files = []metalv1alpha1.File{{Node: metalv1alpha1.Node{Path: "/etc/chrony.conf"}, FileEmbedded1: metalv1alpha1.FileEmbedded1{Mode: ptr.To(0644)}}}
+				ign3.Spec.Storage.Files = ign2.Spec.Storage.Files
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign3)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				Expect(meta.IsStatusConditionFalse(ign.Status.Conditions, metalv1alpha1.ConflictsType)).To(BeTrue())
+			})
+
+			It("when IgnitionV3 are merged, should record which of them set each key in the provenance config map", func() {
+				ign2.Spec.Passwd.Groups = []metalv1alpha1.PasswdGroup{{Name: "ignition-3 value", Gid: ptr.To(2)}}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign3)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				Expect(secret.Annotations).To(HaveKeyWithValue(metalv1alpha1.ProvenanceAnnotation, name+metalv1alpha1.ProvenanceConfigMapSuffix))
+
+				configMap := &corev1.ConfigMap{}
+				Expect(k8sClient.Get(ctx, provenanceNn, configMap)).To(Succeed())
+				Expect(configMap.Data[metalv1alpha1.ProvenanceConfigMapKey]).To(MatchJSON(`{
+					"groups": {"ignition-3 value": "IgnitionV3/test-namespace/test-ignition-3"},
+					"kernelArguments": {"ignition-1 value": "IgnitionV3/test-namespace/test-ignition"},
+					"kernelArgumentsNotExisting": {"ignition-2 value": "IgnitionV3/test-namespace/test-ignition-2"}
+				}`))
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				Expect(ign.Status.MergedIgnitions).To(Equal([]metalv1alpha1.MergedIgnition{
+					{Kind: metalv1alpha1.IgnitionV3Kind, Namespace: namespace, Name: name, Version: validConfigVersion, Keys: 1},
+					{Kind: metalv1alpha1.IgnitionV3Kind, Namespace: namespace, Name: name2, Version: validConfigVersion, Keys: 1},
+					{Kind: metalv1alpha1.IgnitionV3Kind, Namespace: namespace, Name: name3, Version: validConfigVersion, Keys: 1},
+				}))
+			})
+
+			It("when the provenance config map exists without being owned by the IgnitionV3, should create the secret, report it and keep it", func() {
+				configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: provenanceNn.Name, Namespace: namespace}}
+				configMap.Data = map[string]string{"other": "value"}
+				Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				Expect(secret.Data).To(HaveKey(secretConfigData))
+				Expect(secret.Annotations).NotTo(HaveKey(metalv1alpha1.ProvenanceAnnotation))
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.SecretType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+				Expect(condition.Reason).To(Equal("ProvenanceNotOwned"))
+				Expect(k8sClient.Get(ctx, provenanceNn, configMap)).To(Succeed())
+				Expect(configMap.Data).To(Equal(map[string]string{"other": "value"}))
+			})
+
+			It("when a merged IgnitionV3 has an older version, should create a secret with translated config", func() {
+				ign2.Spec.Ignition.Version = "3.2.0"
+				ign2.Spec.KernelArguments.ShouldNotExist = nil
+				ign2.Spec.Passwd.Groups = []metalv1alpha1.PasswdGroup{{Name: "ignition-2 value"}}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["ignition-1 value"]},"passwd":{"groups":[{"name":"ignition-2 value"}]},"storage":{},"systemd":{}}`)))
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				Expect(ign.Status.MergedIgnitions).To(ContainElement(metalv1alpha1.MergedIgnition{Kind: metalv1alpha1.IgnitionV3Kind, Namespace: namespace, Name: name2, Version: "3.2.0", Keys: 1}))
+			})
+
+			It("when config has files, directories and links in upstream layout, should create a secret containing them", func() {
+				ign.Spec.Ignition.Config.Merge = nil
+				ign.Spec.Storage.Files = []metalv1alpha1.File{{
+					Node:          metalv1alpha1.Node{Path: "/etc/hostname"},
+					FileEmbedded1: metalv1alpha1.FileEmbedded1{Contents: metalv1alpha1.Resource{Source: ptr.To("data:,host")}, Mode: ptr.To(420)},
+				}}
+				ign.Spec.Storage.Directories = []metalv1alpha1.Directory{{Node: metalv1alpha1.Node{Path: "/etc/khalkeon"}}}
+				ign.Spec.Storage.Links = []metalv1alpha1.Link{{
+					Node:          metalv1alpha1.Node{Path: "/etc/khalkeon/hostname"},
+					LinkEmbedded1: metalv1alpha1.LinkEmbedded1{Target: ptr.To("/etc/hostname")},
+				}}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["ignition-1 value"]},"passwd":{},"storage":{"directories":[{"group":{},"path":"/etc/khalkeon","user":{}}],"files":[{"group":{},"path":"/etc/hostname","user":{},"contents":{"source":"data:,host","verification":{}},"mode":420}],"links":[{"group":{},"path":"/etc/khalkeon/hostname","user":{},"target":"/etc/hostname"}]},"systemd":{}}`)))
+			})
+
+			It("when a merged IgnitionV3 has a butane config, should create a secret with the translated config", func() {
+				ign2.Spec.KernelArguments.ShouldNotExist = nil
+				ign2.Spec.Butane = "variant: fcos\nversion: 1.6.0\nstorage:\n  files:\n    - path: /etc/motd\n      contents:\n        inline: hello\n"
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["ignition-1 value"]},"passwd":{},"storage":{"files":[{"group":{},"path":"/etc/motd","user":{},"contents":{"compression":"","source":"data:,hello","verification":{}}}]},"systemd":{}}`)))
+			})
+
+			It("when a merged IgnitionV3 has a raw config, should create a secret with fields which aren't part of the API", func() {
+				ign2.Spec.KernelArguments.ShouldNotExist = nil
+				ign2.Spec.Raw = &runtime.RawExtension{Raw: []byte(`{"ignition":{"version":"3.5.0","config":{"merge":[{"source":"https://example.com/base.ign"}]}}}`)}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"merge":[{"source":"https://example.com/base.ign","verification":{}}],"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["ignition-1 value"]},"passwd":{},"storage":{},"systemd":{}}`)))
+			})
+
+			It("when output version is older, should create a secret with config in that version", func() {
+				ign.Spec.Ignition.Config.Merge = nil
+				ign.Spec.KernelArguments.ShouldExist = nil
+				ign.Spec.Passwd.Groups = []metalv1alpha1.PasswdGroup{{Name: "ignition-1 value"}}
+				ign.Spec.OutputVersion = "3.3.0"
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				Expect(secret.Data[secretConfigData]).To(ContainSubstring(`"version":"3.3.0"`))
+				Expect(secret.Data[secretConfigData]).To(ContainSubstring(`"groups":[{"name":"ignition-1 value"}]`))
+			})
+
+			It("when merged config exceeds the secret size limit, should create a secret with the config compressed", func() {
+				DeferCleanup(func(size int) { maxSecretDataSize = size }, maxSecretDataSize)
+				maxSecretDataSize = 700
+				ign.Spec.Ignition.Config.Merge = nil
+				ign.Spec.Systemd.Units = []metalv1alpha1.Unit{{Name: "large.service", Contents: ptr.To(strings.Repeat("ExecStart=/bin/true\n", 100))}}
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				Expect(len(secret.Data[secretConfigData])).To(BeNumerically("<=", maxSecretDataSize))
+				config, _, err := ignitionConfig.Parse(secret.Data[secretConfigData])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Ignition.Config.Replace.Compression).To(Equal(ptr.To("gzip")))
+				Expect(*config.Ignition.Config.Replace.Source).To(HavePrefix("data:;base64,"))
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				Expect(ign.Status.OutputStrategy).To(Equal(metalv1alpha1.OutputStrategyCompressed))
+				Expect(ign.Status.RenderedSize).To(BeNumerically(">", maxSecretDataSize))
+			})
+
+			It("when merged config exceeds the secret size limit even when compressed, should update the IgnitionV3 status to false and not create a secret", func() {
+				DeferCleanup(func(size int) { maxSecretDataSize = size }, maxSecretDataSize)
+				maxSecretDataSize = 100
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConfigurationType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+				Expect(condition.Reason).To(Equal("SecretSizeExceeded"))
+				Expect(k8sClient.Get(ctx, secretNn, secret)).NotTo(Succeed())
+			})
+
+			It("when merged config uses fields unsupported by the output version, should update the IgnitionV3 status to false and not create a secret", func() {
+				ign.Spec.OutputVersion = "3.2.0"
+				Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+				Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+
+				controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+				_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+				condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConfigurationType)
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+				Expect(condition.Reason).To(Equal("OutputVersionUnsupported"))
+				Expect(condition.Message).To(Equal("merged configuration can't be rendered in ignition version 3.2.0, unsupported fields: $.kernelArguments"))
+				Expect(k8sClient.Get(ctx, secretNn, secret)).NotTo(Succeed())
+			})
+
+			When("Ignition has merge refs field", func() {
+				BeforeEach(func() {
+					ign.Spec.Ignition.Config.Merge = nil
+					ign.Spec.Ignition.Config.MergeRefs = []corev1.LocalObjectReference{{Name: name3}, {Name: name2}}
+					ign2.Spec.Ignition.Config.Merge = nil
+					ign2.Spec.Passwd.Groups = []metalv1alpha1.PasswdGroup{{Name: "ignition-3 value", Gid: ptr.To(2)}}
+					ign3.Spec.Passwd.Groups[0].Gid = ptr.To(3)
+				})
+
+				It("when all references exist, should create a secret with config merged in the given order", func() {
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+					Expect(k8sClient.Create(ctx, ign3)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					Expect(controller.ignitionToReferencingIgnitions(ctx, ign3)).To(ConsistOf(reconcile.Request{NamespacedName: nn}))
+
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+					Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["ignition-1 value"],"shouldNotExist":["ignition-2 value"]},"passwd":{"groups":[{"gid":2,"name":"ignition-3 value"}]},"storage":{},"systemd":{}}`)))
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					Expect(meta.IsStatusConditionTrue(ign.Status.Conditions, metalv1alpha1.ReferencesType)).To(BeTrue())
+				})
+
+				It("when a reference doesn't exist, should update the IgnitionV3 status to false and not create a secret", func() {
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ReferencesType)
+					Expect(condition).NotTo(BeNil())
+					Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+					Expect(condition.Message).To(Equal("Referenced ignitions don't exist: test-namespace/test-ignition-3"))
+					Expect(k8sClient.Get(ctx, secretNn, secret)).NotTo(Succeed())
+				})
+			})
+
+			When("Ignition has merge from field", func() {
+				const (
+					sourceSecretName    = "test-source-secret"
+					sourceConfigMapName = "test-source-configmap"
+				)
+
+				var (
+					sourceSecret    *corev1.Secret
+					sourceConfigMap *corev1.ConfigMap
+				)
+
+				BeforeEach(func() {
+					ign.Spec.Ignition.Config.Merge = nil
+					ign.Spec.Ignition.Config.MergeFrom = []metalv1alpha1.ConfigSource{
+						{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: sourceSecretName}, Key: "ignition"}},
+						{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: sourceConfigMapName}, Key: "ignition"}},
+					}
+					sourceSecret = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: sourceSecretName, Namespace: namespace}}
+					sourceSecret.Data = map[string][]byte{"ignition": []byte(`{"ignition":{"version":"3.2.0"},"passwd":{"groups":[{"name":"secret value"}]}}`)}
+					sourceConfigMap = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: sourceConfigMapName, Namespace: namespace}}
+					sourceConfigMap.Data = map[string]string{"ignition": `{"ignition":{"version":"3.5.0"},"passwd":{"groups":[{"name":"secret value","gid":2}]}}`}
+				})
+
+				AfterEach(func() {
+					deleteIfPresent(sourceSecret)
+					deleteIfPresent(sourceConfigMap)
+				})
+
+				It("when all sources exist, should create a secret with config merged in the given order", func() {
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, sourceSecret)).To(Succeed())
+					Expect(k8sClient.Create(ctx, sourceConfigMap)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					Expect(controller.configSourceToIgnitions(ctx, sourceConfigMap)).To(ConsistOf(reconcile.Request{NamespacedName: nn}))
+
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+					Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["ignition-1 value"]},"passwd":{"groups":[{"gid":2,"name":"secret value"}]},"storage":{},"systemd":{}}`)))
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					Expect(ign.Status.MergedIgnitions).To(Equal([]metalv1alpha1.MergedIgnition{
+						{Kind: metalv1alpha1.IgnitionV3Kind, Namespace: namespace, Name: name, Version: validConfigVersion, Keys: 1},
+						{Kind: metalv1alpha1.SecretKind, Namespace: namespace, Name: sourceSecretName, Version: "3.2.0"},
+						{Kind: metalv1alpha1.ConfigMapKind, Namespace: namespace, Name: sourceConfigMapName, Version: validConfigVersion, Keys: 1},
+					}))
+				})
+
+				It("when a source doesn't exist, should update the IgnitionV3 status to false and not create a secret", func() {
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, sourceSecret)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ReferencesType)
+					Expect(condition).NotTo(BeNil())
+					Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+					Expect(condition.Message).To(Equal("Referenced config sources don't exist: ConfigMap test-namespace/test-source-configmap (key ignition)"))
+					Expect(k8sClient.Get(ctx, secretNn, secret)).NotTo(Succeed())
+				})
+
+				It("when a file has contents from a source, should create a secret with the contents inlined", func() {
+					ign.Spec.Ignition.Config.MergeFrom = nil
+					ign.Spec.Storage.Files = []metalv1alpha1.File{
+						{
+							Node: metalv1alpha1.Node{Path: "/etc/motd"},
+							FileEmbedded1: metalv1alpha1.FileEmbedded1{ContentsFrom: &metalv1alpha1.FileContentsSource{
+								ConfigSource: metalv1alpha1.ConfigSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: sourceConfigMapName}, Key: "motd"}},
+							}},
+						},
+						{
+							Node: metalv1alpha1.Node{Path: "/etc/token"},
+							FileEmbedded1: metalv1alpha1.FileEmbedded1{ContentsFrom: &metalv1alpha1.FileContentsSource{
+								ConfigSource: metalv1alpha1.ConfigSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: sourceSecretName}, Key: "token"}},
+								Compression:  ptr.To("gzip"),
+							}},
+						},
+					}
+					sourceConfigMap.Data = map[string]string{"motd": "hello"}
+					sourceSecret.Data = map[string][]byte{"token": []byte("secret token")}
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, sourceSecret)).To(Succeed())
+					Expect(k8sClient.Create(ctx, sourceConfigMap)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					Expect(controller.configSourceToIgnitions(ctx, sourceSecret)).To(ConsistOf(reconcile.Request{NamespacedName: nn}))
+
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+					config, _, err := ignitionConfig.Parse(secret.Data[secretConfigData])
+					Expect(err).NotTo(HaveOccurred())
+					Expect(config.Storage.Files).To(HaveLen(2))
+					Expect(config.Storage.Files[0].Contents.Source).To(Equal(ptr.To("data:;base64,aGVsbG8=")))
+					Expect(config.Storage.Files[0].Contents.Verification.Hash).To(Equal(ptr.To("sha512-9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043")))
+					Expect(config.Storage.Files[1].Contents.Compression).To(Equal(ptr.To("gzip")))
+					Expect(config.Storage.Files[1].Contents.Verification.Hash).NotTo(BeNil())
+				})
+
+				It("when a user has credentials from secrets, should create a secret with the credentials resolved", func() {
+					ign.Spec.Ignition.Config.MergeFrom = nil
+					ign.Spec.Passwd.Users = []metalv1alpha1.PasswdUser{{
+						Name:              "core",
+						PasswordHashFrom:  &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: sourceSecretName}, Key: "passwordHash"},
+						SSHAuthorizedKeys: []metalv1alpha1.SSHAuthorizedKey{"ssh-ed25519 inline"},
+						SSHAuthorizedKeysFrom: []corev1.SecretKeySelector{
+							{LocalObjectReference: corev1.LocalObjectReference{Name: sourceSecretName}, Key: "authorizedKeys"},
+						},
+					}}
+					sourceSecret.Data = map[string][]byte{
+						"passwordHash":   []byte("$6$hash\n"),
+						"authorizedKeys": []byte("# admins\nssh-ed25519 first\n\nssh-rsa second\n"),
+					}
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, sourceSecret)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					Expect(controller.configSourceToIgnitions(ctx, sourceSecret)).To(ConsistOf(reconcile.Request{NamespacedName: nn}))
+
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+					config, _, err := ignitionConfig.Parse(secret.Data[secretConfigData])
+					Expect(err).NotTo(HaveOccurred())
+					Expect(config.Passwd.Users).To(HaveLen(1))
+					Expect(config.Passwd.Users[0].PasswordHash).To(Equal(ptr.To("$6$hash")))
+					Expect(config.Passwd.Users[0].SSHAuthorizedKeys).To(Equal([]ignitiontypes.SSHAuthorizedKey{"ssh-ed25519 inline", "ssh-ed25519 first", "ssh-rsa second"}))
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					Expect(ign.Spec.Passwd.Users[0].PasswordHash).To(BeNil())
+				})
+
+				It("when secret data is merged, should not write it to config map outputs", func() {
+					outputSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "test-output-secret", Namespace: namespace}}
+					outputConfigMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test-output-configmap", Namespace: namespace}}
+					DeferCleanup(func() {
+						deleteIfPresent(outputSecret)
+						deleteIfPresent(outputConfigMap)
+					})
+					ign.Spec.Ignition.Config.MergeFrom = nil
+					ign.Spec.Passwd.Users = []metalv1alpha1.PasswdUser{{
+						Name:             "core",
+						PasswordHashFrom: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: sourceSecretName}, Key: "passwordHash"},
+					}}
+					ign.Spec.Outputs = []metalv1alpha1.IgnitionV3Output{
+						{Name: "secret", Secret: &metalv1alpha1.TargetSecret{Name: outputSecret.Name}},
+						{Name: "configmap", ConfigMap: &metalv1alpha1.TargetConfigMap{Name: outputConfigMap.Name}},
+					}
+					sourceSecret.Data = map[string][]byte{"passwordHash": []byte("$6$hash")}
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, sourceSecret)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(outputSecret), outputSecret)).To(Succeed())
+					Expect(outputSecret.Data[secretConfigData]).To(ContainSubstring("$6$hash"))
+					Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(outputConfigMap), outputConfigMap)).NotTo(Succeed())
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					Expect(ign.Status.Outputs).To(HaveLen(2))
+					Expect(ign.Status.Outputs[0].Ready).To(BeTrue())
+					Expect(ign.Status.Outputs[1].Ready).To(BeFalse())
+					Expect(ign.Status.Outputs[1].Message).To(ContainSubstring(namespace + "/" + sourceSecretName))
+				})
+
+				It("when a missing source is optional, should create a secret without it", func() {
+					ign.Spec.Ignition.Config.MergeFrom[1].ConfigMapKeyRef.Optional = ptr.To(true)
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, sourceSecret)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+					Expect(secret.Data[secretConfigData]).To(ContainSubstring(`"groups":[{"name":"secret value"}]`))
+				})
+			})
+
+			When("Ignition has replace field", func() {
+				const (
+					replaceName = "test-ignition-replace"
+				)
+
+				var (
+					replaceIgn *metalv1alpha1.IgnitionV3
+				)
+
+				BeforeEach(func() {
+					replaceIgn = &metalv1alpha1.IgnitionV3{ObjectMeta: metav1.ObjectMeta{Name: replaceName, Namespace: namespace}}
+					replaceIgn.Spec.Ignition.Version = validConfigVersion
+					replaceIgn.Spec.KernelArguments.ShouldExist = []metalv1alpha1.KernelArgument{"replace ignition value"}
+					replaceIgn.Spec.Passwd.Groups = []metalv1alpha1.PasswdGroup{{Name: "replace ignition value"}}
+				})
+
+				AfterEach(func() {
+					deleteIfPresent(replaceIgn, withFinalizers)
+				})
+
+				It("when an IgnitionV3 has a replace loop, should update the IgnitionV3 status to false", func() {
+					replaceIgn.Spec.Ignition.Config.Replace = &corev1.LocalObjectReference{Name: replaceName}
+					Expect(k8sClient.Create(ctx, replaceIgn)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(replaceIgn), replaceIgn)).To(Succeed())
+					Expect(meta.IsStatusConditionTrue(replaceIgn.Status.Conditions, metalv1alpha1.ConfigurationType)).To(BeFalse())
+				})
+
+				It("when an IgnitionV3 with target secret is replaced with non existing IgnitionV3, should return an error", func() {
+					ign.Spec.Ignition.Config.Replace = &corev1.LocalObjectReference{Name: replaceName}
+					ign.Spec.Ignition.Config.Merge = nil
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err.Error()).To(Equal(`couldn't create merged configuration: couldn't get ignition. Reason: ignitionv3s.metal.cobaltcore.dev "test-ignition-replace" not found`))
+				})
+
+				It("when an IgnitionV3 with target secret is replaced with existing IgnitionV3, should create a secret with replaced config", func() {
+					ign.Spec.Ignition.Config.Replace = &corev1.LocalObjectReference{Name: replaceName}
+					ign.Spec.Ignition.Config.Merge = nil
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, replaceIgn)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+					Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["replace ignition value"]},"passwd":{"groups":[{"name":"replace ignition value"}]},"storage":{},"systemd":{}}`)))
+				})
+
+				It("when an IgnitionV3 collected with merge is replaced with existing IgnitionV3, should create a secret with replaced config", func() {
+					ign3.Spec.Ignition.Config.Replace = &corev1.LocalObjectReference{Name: replaceName}
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+					Expect(k8sClient.Create(ctx, ign3)).To(Succeed())
+					Expect(k8sClient.Create(ctx, replaceIgn)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ign3)})
+					Expect(err).NotTo(HaveOccurred())
+					_, err = controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+					Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["ignition-1 value","replace ignition value"],"shouldNotExist":["ignition-2 value"]},"passwd":{"groups":[{"name":"replace ignition value"}]},"storage":{},"systemd":{}}`)))
+				})
+			})
+
+			When("Ignition has cluster merge field", func() {
+				const (
+					clusterName = "test-cluster-ignition"
+				)
+
+				var (
+					clusterIgn *metalv1alpha1.ClusterIgnitionV3
+				)
+
+				BeforeEach(func() {
+					clusterLabels := map[string]string{"cluster-merge": "true"}
+					clusterIgn = &metalv1alpha1.ClusterIgnitionV3{ObjectMeta: metav1.ObjectMeta{Name: clusterName, Labels: clusterLabels}}
+					clusterIgn.Spec.Ignition.Version = validConfigVersion
+					clusterIgn.Spec.Passwd.Groups = []metalv1alpha1.PasswdGroup{{Name: "cluster ignition value"}}
+					ign.Spec.Ignition.Config.ClusterMerge = &metav1.LabelSelector{MatchLabels: clusterLabels}
+				})
+
+				AfterEach(func() {
+					deleteIfPresent(clusterIgn)
+				})
+
+				It("when a ClusterIgnitionV3 is selected, should create a secret with merged config and update its status", func() {
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+					Expect(k8sClient.Create(ctx, clusterIgn)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+					Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["ignition-1 value"],"shouldNotExist":["ignition-2 value"]},"passwd":{"groups":[{"name":"cluster ignition value"}]},"storage":{},"systemd":{}}`)))
+
+					Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(clusterIgn), clusterIgn)).To(Succeed())
+					Expect(clusterIgn.Status.TargetIgnitions).To(ConsistOf(metalv1alpha1.IgnitionReference{Namespace: namespace, Name: name}))
+				})
+
+				It("when a ClusterIgnitionV3 is selected twice, should merge it once", func() {
+					sharedLabels := map[string]string{"cluster-merge": "true", "shared": "true"}
+					sharedIgn := &metalv1alpha1.ClusterIgnitionV3{ObjectMeta: metav1.ObjectMeta{Name: clusterName + "-shared", Labels: sharedLabels}}
+					sharedIgn.Spec.Ignition.Version = validConfigVersion
+					sharedIgn.Spec.Passwd.Groups = []metalv1alpha1.PasswdGroup{{Name: "shared cluster ignition value"}}
+					Expect(k8sClient.Create(ctx, sharedIgn)).To(Succeed())
+					DeferCleanup(func() {
+						deleteIfPresent(sharedIgn)
+					})
+					clusterIgn.Spec.Ignition.Config.ClusterMerge = &metav1.LabelSelector{MatchLabels: map[string]string{"shared": "true"}}
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, clusterIgn)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					Expect(meta.IsStatusConditionTrue(ign.Status.Conditions, metalv1alpha1.ConfigurationType)).To(BeTrue())
+					shared := slices.DeleteFunc(slices.Clone(ign.Status.MergedIgnitions), func(merged metalv1alpha1.MergedIgnition) bool {
+						return merged.Name != sharedIgn.Name
+					})
+					Expect(shared).To(HaveLen(1))
+					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+				})
+
+				It("when ClusterIgnitionV3 select each other, should update the IgnitionV3 status to false", func() {
+					clusterIgn.Spec.Ignition.Config.ClusterMerge = &metav1.LabelSelector{MatchLabels: clusterIgn.Labels}
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, clusterIgn)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConfigurationType)
+					Expect(condition).NotTo(BeNil())
+					Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+					Expect(condition.Reason).To(Equal("ClusterIgnitionLoop"))
+					Expect(condition.Message).To(Equal("cluster ignitions select each other with clusterMerge: test-cluster-ignition -> test-cluster-ignition"))
+					Expect(k8sClient.Get(ctx, secretNn, secret)).NotTo(Succeed())
+				})
+
+				It("when a ClusterIgnitionV3 changes, should enqueue the IgnitionV3 selecting it", func() {
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, clusterIgn)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					Expect(controller.clusterIgnitionToIgnitions(ctx, clusterIgn)).To(ConsistOf(reconcile.Request{NamespacedName: nn}))
+				})
+
+				It("when a ClusterIgnitionV3 has merge field, should be rejected", func() {
+					clusterIgn.Spec.Ignition.Config.Merge = &metav1.LabelSelector{MatchLabels: map[string]string{"merge": "true"}}
+					Expect(k8sClient.Create(ctx, clusterIgn)).NotTo(Succeed())
+				})
+
+				It("when a ClusterIgnitionV3 has namespace selector field, should be rejected", func() {
+					clusterIgn.Spec.Ignition.Config.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}
+					Expect(k8sClient.Create(ctx, clusterIgn)).NotTo(Succeed())
+				})
+			})
+
+			When("Ignition has namespace selector field", func() {
+				const (
+					grantName = "test-ignition-grant"
+				)
+
+				var (
+					sourceIgn *metalv1alpha1.IgnitionV3
+					grant     *metalv1alpha1.IgnitionV3Grant
+				)
+
+				BeforeEach(func() {
+					ign.Spec.Ignition.Config.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"ignition-source": "true"}}
+
+					sourceIgn = &metalv1alpha1.IgnitionV3{ObjectMeta: metav1.ObjectMeta{Name: name3, Namespace: sourceNamespace}}
+					sourceIgn.Spec.Ignition.Version = validConfigVersion
+					sourceIgn.Spec.Passwd.Groups = []metalv1alpha1.PasswdGroup{{Name: "source ignition value"}}
+					sourceIgn.Labels = map[string]string{"merge": "true"}
+
+					grant = &metalv1alpha1.IgnitionV3Grant{ObjectMeta: metav1.ObjectMeta{Name: grantName, Namespace: sourceNamespace}}
+					grant.Spec.From = []metalv1alpha1.IgnitionV3GrantFrom{{Namespace: namespace}}
+				})
+
+				AfterEach(func() {
+					deleteIfPresent(sourceIgn, withFinalizers)
+					deleteIfPresent(grant)
+				})
+
+				It("when the source namespace doesn't grant access, should update the IgnitionV3 status to false", func() {
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, sourceIgn)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConfigurationType)
+					Expect(condition).NotTo(BeNil())
+					Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+					Expect(condition.Reason).To(Equal("MergeNotPermitted"))
+					Expect(k8sClient.Get(ctx, secretNn, secret)).NotTo(Succeed())
+				})
+
+				It("when the source namespace grants access, should create a secret with merged config", func() {
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, ign2)).To(Succeed())
+					Expect(k8sClient.Create(ctx, sourceIgn)).To(Succeed())
+					Expect(k8sClient.Create(ctx, grant)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					Expect(controller.ignitionToCrossNamespaceIgnitions(ctx, sourceIgn)).To(ConsistOf(reconcile.Request{NamespacedName: nn}))
+
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					Expect(meta.IsStatusConditionTrue(ign.Status.Conditions, metalv1alpha1.ConfigurationType)).To(BeTrue())
+					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+					Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["ignition-1 value"],"shouldNotExist":["ignition-2 value"]},"passwd":{"groups":[{"name":"source ignition value"}]},"storage":{},"systemd":{}}`)))
+
+					Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(sourceIgn), sourceIgn)).To(Succeed())
+					Expect(sourceIgn.Status.TargetIgnitions).To(ConsistOf(metalv1alpha1.IgnitionReference{Namespace: namespace, Name: name}))
+					matchAll := func(*metalv1alpha1.IgnitionV3) bool { return true }
+					Expect(ignitionsWithTargets([]metalv1alpha1.IgnitionV3{*sourceIgn}, matchAll)).To(ConsistOf(
+						reconcile.Request{NamespacedName: client.ObjectKeyFromObject(sourceIgn)}, reconcile.Request{NamespacedName: nn}))
+				})
+
+				It("when a namespace label changes, should enqueue the IgnitionV3 selecting namespaces", func() {
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					Expect(controller.grantToIgnitions(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: sourceNamespace}})).To(ConsistOf(reconcile.Request{NamespacedName: nn}))
+				})
+			})
+
+			When("Ignition has variables", func() {
+				const (
+					variablesConfigMapName = "test-variables-configmap"
+				)
+
+				var (
+					variablesConfigMap *corev1.ConfigMap
+				)
+
+				BeforeEach(func() {
+					ign.Spec.Ignition.Config.Merge = nil
+					ign.Spec.KernelArguments.ShouldExist = []metalv1alpha1.KernelArgument{"hostname=${hostname}"}
+					ign.Spec.Systemd.Units = []metalv1alpha1.Unit{{Name: "rack.service", Contents: ptr.To("Environment=RACK=${rack} HOME=$${HOME}")}}
+					ign.Spec.Variables = map[string]string{"hostname": "node-1"}
+					ign.Spec.VariablesFrom = []corev1.LocalObjectReference{{Name: variablesConfigMapName}}
+					variablesConfigMap = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: variablesConfigMapName, Namespace: namespace}}
+					variablesConfigMap.Data = map[string]string{"hostname": "overridden", "rack": "r42"}
+				})
+
+				AfterEach(func() {
+					deleteIfPresent(variablesConfigMap)
+				})
+
+				It("when all variables are defined, should create a secret with the variables substituted", func() {
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, variablesConfigMap)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					Expect(controller.configSourceToIgnitions(ctx, variablesConfigMap)).To(ConsistOf(reconcile.Request{NamespacedName: nn}))
+
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+					Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["hostname=node-1"]},"passwd":{},"storage":{},"systemd":{"units":[{"contents":"Environment=RACK=r42 HOME=${HOME}","name":"rack.service"}]}}`)))
+				})
+
+				It("when a variable is undefined, should update the IgnitionV3 status to false and not create a secret", func() {
+					variablesConfigMap.Data = map[string]string{}
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, variablesConfigMap)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConfigurationType)
+					Expect(condition).NotTo(BeNil())
+					Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+					Expect(condition.Reason).To(Equal("VariablesUndefined"))
+					Expect(condition.Message).To(Equal("merged configuration uses undefined variables: rack"))
+					Expect(k8sClient.Get(ctx, secretNn, secret)).NotTo(Succeed())
+				})
+
+				It("when a substituted kernel argument is repeated, should create a secret with it once and report it", func() {
+					ign.Spec.KernelArguments.ShouldExist = append(ign.Spec.KernelArguments.ShouldExist, "hostname=node-1")
+					variablesConfigMap.Data = map[string]string{"rack": "r42"}
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, variablesConfigMap)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+					Expect(secret.Data[secretConfigData]).To(ContainSubstring(`"kernelArguments":{"shouldExist":["hostname=node-1"]}`))
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConfigurationType)
+					Expect(condition).NotTo(BeNil())
+					Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+					Expect(condition.Reason).To(Equal("KernelArgumentsDeduplicated"))
+					Expect(condition.Message).To(Equal("Repeated kernel arguments were dropped: hostname=node-1"))
+				})
+
+				It("when a substituted kernel argument is repeated and butane config has warnings, should report both", func() {
+					ign.Spec.KernelArguments.ShouldExist = append(ign.Spec.KernelArguments.ShouldExist, "hostname=node-1")
+					ign.Spec.Butane = "variant: fcos\nversion: 1.6.0\nunknown: value\n"
+					variablesConfigMap.Data = map[string]string{"rack": "r42"}
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, variablesConfigMap)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConfigurationType)
+					Expect(condition).NotTo(BeNil())
+					Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+					Expect(condition.Reason).To(Equal("ConversionSucceededWithWarnings,KernelArgumentsDeduplicated"))
+					Expect(condition.Message).To(HavePrefix("Butane translation reported warnings: "))
+					Expect(condition.Message).To(HaveSuffix("; Repeated kernel arguments were dropped: hostname=node-1"))
+				})
+
+				It("when a substituted kernel argument is required and forbidden, should update the IgnitionV3 status to false and not create a secret", func() {
+					ign.Spec.KernelArguments.ShouldNotExist = []metalv1alpha1.KernelArgument{"hostname=node-1"}
+					variablesConfigMap.Data = map[string]string{"rack": "r42"}
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, variablesConfigMap)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ConfigurationType)
+					Expect(condition).NotTo(BeNil())
+					Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+					Expect(condition.Reason).To(Equal("KernelArgumentsContradictory"))
+					Expect(k8sClient.Get(ctx, secretNn, secret)).NotTo(Succeed())
+				})
+
+				It("when no variables are set, should create a secret with escaped placeholders unescaped and the other ones kept", func() {
+					ign.Spec.Variables = nil
+					ign.Spec.VariablesFrom = nil
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+					Expect(secret.Data[secretConfigData]).To(Equal([]byte(`{"ignition":{"config":{"replace":{"verification":{}}},"proxy":{},"security":{"tls":{}},"timeouts":{},"version":"3.5.0"},"kernelArguments":{"shouldExist":["hostname=${hostname}"]},"passwd":{},"storage":{},"systemd":{"units":[{"contents":"Environment=RACK=${rack} HOME=${HOME}","name":"rack.service"}]}}`)))
+				})
+
+				It("when variables are set without a target secret, should be rejected", func() {
+					ign.Spec.TargetSecret = nil
+					Expect(k8sClient.Create(ctx, ign)).NotTo(Succeed())
+				})
+			})
+
+			When("Ignition has a pointer config", func() {
+				const (
+					tokenSecretName = "test-token-secret"
+				)
+
+				var (
+					tokenSecret    *corev1.Secret
+					renderedSecret *corev1.Secret
+					renderedNn     = types.NamespacedName{Name: name + metalv1alpha1.RenderedSecretSuffix, Namespace: namespace}
+				)
+
+				BeforeEach(func() {
+					ign.Spec.Ignition.Config.Merge = nil
+					ign.Spec.TokenFrom = &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: tokenSecretName}, Key: "token"}
+					ign.Spec.Pointer = &metalv1alpha1.IgnitionV3Pointer{URL: "http://khalkeon.example.com:8080/"}
+					tokenSecret = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: tokenSecretName, Namespace: namespace}}
+					tokenSecret.Data = map[string][]byte{"token": []byte("secret-token\n")}
+					renderedSecret = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: renderedNn.Name, Namespace: namespace}}
+				})
+
+				AfterEach(func() {
+					deleteIfPresent(tokenSecret)
+					deleteIfPresent(renderedSecret)
+				})
+
+				It("when the rendered secret exists without being owned by the IgnitionV3, should update the IgnitionV3 status to false and keep it", func() {
+					renderedSecret.Data = map[string][]byte{"other": []byte("value")}
+					Expect(k8sClient.Create(ctx, renderedSecret)).To(Succeed())
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, tokenSecret)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.SecretType)
+					Expect(condition).NotTo(BeNil())
+					Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+					Expect(condition.Reason).To(Equal("ObjectNotOwned"))
+					Expect(k8sClient.Get(ctx, renderedNn, renderedSecret)).To(Succeed())
+					Expect(renderedSecret.Data).To(Equal(map[string][]byte{"other": []byte("value")}))
+					Expect(k8sClient.Get(ctx, secretNn, secret)).NotTo(Succeed())
+				})
+
+				It("should create a secret with a pointer to the merged config and its hash", func() {
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, tokenSecret)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					Expect(controller.configSourceToIgnitions(ctx, tokenSecret)).To(ConsistOf(reconcile.Request{NamespacedName: nn}))
+
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, renderedNn, renderedSecret)).To(Succeed())
+					Expect(renderedSecret.Data[secretConfigData]).To(ContainSubstring(`"shouldExist":["ignition-1 value"]`))
+					hash := sha512.Sum512(renderedSecret.Data[secretConfigData])
+
+					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+					config, _, err := ignitionConfig.Parse(secret.Data[secretConfigData])
+					Expect(err).NotTo(HaveOccurred())
+					Expect(config.KernelArguments.ShouldExist).To(BeEmpty())
+					replace := config.Ignition.Config.Replace
+					Expect(replace.Source).To(Equal(ptr.To("http://khalkeon.example.com:8080/ignition/" + namespace + "/" + name)))
+					Expect(replace.Verification.Hash).To(Equal(ptr.To("sha512-" + hex.EncodeToString(hash[:]))))
+					Expect(replace.HTTPHeaders).To(ConsistOf(ignitiontypes.HTTPHeader{Name: "Authorization", Value: ptr.To("Bearer secret-token")}))
+				})
+
+				It("when the pointer is removed, should write the merged config to the secret and delete the rendered secret", func() {
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+					Expect(k8sClient.Create(ctx, tokenSecret)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(k8sClient.Get(ctx, renderedNn, renderedSecret)).To(Succeed())
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					ign.Spec.Pointer = nil
+					Expect(k8sClient.Update(ctx, ign)).To(Succeed())
+					_, err = controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, secretNn, secret)).To(Succeed())
+					Expect(secret.Data[secretConfigData]).To(ContainSubstring(`"shouldExist":["ignition-1 value"]`))
+					Expect(apierrors.IsNotFound(k8sClient.Get(ctx, renderedNn, renderedSecret))).To(BeTrue())
+				})
+
+				It("when the token secret doesn't exist, should update the IgnitionV3 status to false and not create a secret", func() {
+					Expect(k8sClient.Create(ctx, ign)).To(Succeed())
+
+					controller := &IgnitionV3Reconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+					_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(k8sClient.Get(ctx, nn, ign)).To(Succeed())
+					condition := meta.FindStatusCondition(ign.Status.Conditions, metalv1alpha1.ReferencesType)
+					Expect(condition).NotTo(BeNil())
+					Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+					Expect(k8sClient.Get(ctx, secretNn, secret)).NotTo(Succeed())
+				})
+
+				It("when a pointer is set without a token, should be rejected", func() {
+					ign.Spec.TokenFrom = nil
+					Expect(k8sClient.Create(ctx, ign)).NotTo(Succeed())
+				})
+			})
+		})
+	})
+})
+
+var _ = Describe("convert", func() {
+	It("should round-trip a config in upstream ignition layout", func() {
+		upstream := []byte(`{
+			"ignition": {"version": "3.5.0"},
+			"storage": {
+				"directories": [{"path": "/etc/khalkeon", "mode": 493, "overwrite": true}],
+				"files": [{
+					"path": "/etc/khalkeon/config",
+					"mode": 420,
+					"user": {"name": "core"},
+					"contents": {"source": "data:,hello", "verification": {"hash": "sha256-2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"}},
+					"append": [{"source": "data:,world"}]
+				}],
+				"links": [{"path": "/etc/khalkeon/link", "target": "/etc/khalkeon/config", "hard": false}]
+			},
+			"systemd": {"units": [{"name": "khalkeon.service", "enabled": true, "contents": "[Install]\nWantedBy=multi-user.target"}]}
+		}`)
+		expected, report, err := ignitionConfig.Parse(upstream)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Entries).To(BeEmpty())
+
+		config := metalv1alpha1.Config{}
+		Expect(json.Unmarshal(upstream, &config)).To(Succeed())
+		Expect(config.Storage.Files).To(HaveLen(1))
+		Expect(config.Storage.Files[0].Path).To(Equal("/etc/khalkeon/config"))
+
+		converted, err := convert(config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(converted).To(Equal(expected))
+
+		convertedBytes, err := json.Marshal(converted)
+		Expect(err).NotTo(HaveOccurred())
+		roundTripped := metalv1alpha1.Config{}
+		Expect(json.Unmarshal(convertedBytes, &roundTripped)).To(Succeed())
+		Expect(roundTripped.Storage).To(Equal(config.Storage))
+		Expect(roundTripped.Systemd).To(Equal(config.Systemd))
+	})
+})
+
+var _ = Describe("substituteVariables", func() {
+	It("should substitute variables in compressed data URLs and update their hash", func() {
+		contents, err := encodeFileContents([]byte("rack=${rack}"), ptr.To("gzip"))
+		Expect(err).NotTo(HaveOccurred())
+		config := ignitiontypes.Config{}
+		config.Storage.Files = []ignitiontypes.File{{
+			Node:          ignitiontypes.Node{Path: "/etc/rack"},
+			FileEmbedded1: ignitiontypes.FileEmbedded1{Contents: ignitiontypes.Resource{Compression: contents.Compression, Source: contents.Source, Verification: ignitiontypes.Verification{Hash: contents.Verification.Hash}}},
+		}}
+
+		substituted, err := substituteVariables(config, map[string]string{"rack": "r42"})
+		Expect(err).NotTo(HaveOccurred())
+
+		expected, err := encodeFileContents([]byte("rack=r42"), ptr.To("gzip"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(substituted.Storage.Files[0].Contents.Verification.Hash).To(Equal(expected.Verification.Hash))
+		Expect(config.Storage.Files[0].Contents.Source).To(Equal(contents.Source))
 	})
 })
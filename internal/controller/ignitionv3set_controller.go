@@ -0,0 +1,283 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/yaml"
+
+	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
+)
+
+// instancePlaceholder is replaced with the instance name in the names of the template's targetSecret and outputs.
+const instancePlaceholder = "${instance}"
+
+// IgnitionV3SetReconciler reconciles a IgnitionV3Set object
+type IgnitionV3SetReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=metal.cobaltcore.dev,resources=ignitionv3sets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=metal.cobaltcore.dev,resources=ignitionv3sets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metal.cobaltcore.dev,resources=ignitionv3sets/finalizers,verbs=update
+// +kubebuilder:rbac:groups=metal.cobaltcore.dev,resources=ignitionv3s,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=list;watch
+
+// Reconcile generates a target IgnitionV3 for every instance of an IgnitionV3Set
+// and deletes the generated IgnitionV3 objects of instances which were removed.
+// IgnitionV3 objects which exist without being controlled by the set are kept and reported in the status.
+func (r *IgnitionV3SetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := ctrllog.FromContext(ctx)
+
+	set := &metalv1alpha1.IgnitionV3Set{}
+	if err := r.Get(ctx, req.NamespacedName, set); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !set.DeletionTimestamp.IsZero() {
+		// the generated ignitions are deleted by the garbage collector
+		return ctrl.Result{}, nil
+	}
+	log.Info("Reconcile")
+
+	instances, err := r.listInstances(ctx, set)
+	if err != nil {
+		if patchErr := r.patchInstancesStatus(ctx, set, 0, nil, err); patchErr != nil {
+			return ctrl.Result{}, fmt.Errorf("couldn't patch instances status: %w", patchErr)
+		}
+		var instancesErr *invalidInstancesError
+		if errors.As(err, &instancesErr) {
+			// retrying doesn't help, the set is reconciled again once it or its ConfigMap changes
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("couldn't list instances: %w", err)
+	}
+
+	var notControlled []string
+	for _, instance := range instances {
+		err := r.reconcileInstance(ctx, set, instance)
+		var notControlledErr *ignitionNotControlledError
+		if errors.As(err, &notControlledErr) {
+			// the IgnitionV3 is kept and reported in the status instead of requeueing until it is removed
+			notControlled = append(notControlled, notControlledErr.name)
+			continue
+		}
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("couldn't reconcile instance %s: %w", instance.Name, err)
+		}
+	}
+
+	if err := r.deleteRemovedInstances(ctx, set, instances); err != nil {
+		return ctrl.Result{}, fmt.Errorf("couldn't delete removed instances: %w", err)
+	}
+
+	if err := r.patchInstancesStatus(ctx, set, len(instances)-len(notControlled), notControlled, nil); err != nil {
+		return ctrl.Result{}, fmt.Errorf("couldn't patch instances status: %w", err)
+	}
+	return ctrl.Result{}, nil
+}
+
+// invalidInstancesError is returned when the instances of a set can't be determined from its spec and ConfigMap.
+type invalidInstancesError struct {
+	reason  string
+	message string
+}
+
+func (e *invalidInstancesError) Error() string {
+	return e.message
+}
+
+// ignitionNotControlledError is returned when the IgnitionV3 of an instance exists without being controlled by the set,
+// so it isn't overwritten.
+type ignitionNotControlledError struct {
+	name string
+}
+
+func (e *ignitionNotControlledError) Error() string {
+	return fmt.Sprintf("%s %s exists without being controlled by the set", metalv1alpha1.IgnitionV3Kind, e.name)
+}
+
+// listInstances returns the instances of spec.instances followed by the ones of spec.instancesFrom.
+func (r *IgnitionV3SetReconciler) listInstances(ctx context.Context, set *metalv1alpha1.IgnitionV3Set) ([]metalv1alpha1.IgnitionV3SetInstance, error) {
+	instances := slices.Clone(set.Spec.Instances)
+	if set.Spec.InstancesFrom == nil {
+		return instances, nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	nn := types.NamespacedName{Namespace: set.Namespace, Name: set.Spec.InstancesFrom.Name}
+	if err := r.Get(ctx, nn, configMap); apierrors.IsNotFound(err) {
+		return nil, &invalidInstancesError{reason: "ReferencesMissing", message: fmt.Sprintf("%s %s doesn't exist", metalv1alpha1.ConfigMapKind, nn.String())}
+	} else if err != nil {
+		return nil, fmt.Errorf("couldn't get %s. Reason: %v", metalv1alpha1.ConfigMapKind, err)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(configMap.Data)) {
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+			return nil, &invalidInstancesError{reason: "InstancesInvalid", message: fmt.Sprintf("instance %s of %s %s is invalid: %s", name, metalv1alpha1.ConfigMapKind, nn.String(), strings.Join(errs, ", "))}
+		}
+		if slices.ContainsFunc(instances, func(instance metalv1alpha1.IgnitionV3SetInstance) bool { return instance.Name == name }) {
+			return nil, &invalidInstancesError{reason: "InstancesInvalid", message: fmt.Sprintf("instance %s is defined more than once", name)}
+		}
+		instance := metalv1alpha1.IgnitionV3SetInstance{Name: name}
+		if err := yaml.Unmarshal([]byte(configMap.Data[name]), &instance.Variables); err != nil {
+			return nil, &invalidInstancesError{reason: "InstancesInvalid", message: fmt.Sprintf("couldn't parse variables of instance %s of %s %s. Reason: %v", name, metalv1alpha1.ConfigMapKind, nn.String(), err)}
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// reconcileInstance creates or updates the IgnitionV3 generated for an instance.
+func (r *IgnitionV3SetReconciler) reconcileInstance(ctx context.Context, set *metalv1alpha1.IgnitionV3Set, instance metalv1alpha1.IgnitionV3SetInstance) error {
+	ignition := &metalv1alpha1.IgnitionV3{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instanceIgnitionName(set, instance.Name),
+			Namespace: set.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrPatch(ctx, r.Client, ignition, func() error {
+		if creationTimestamp := ignition.GetCreationTimestamp(); !creationTimestamp.IsZero() && !metav1.IsControlledBy(ignition, set) {
+			return &ignitionNotControlledError{name: ignition.Name}
+		}
+		instanceLabels := maps.Clone(set.Spec.Template.Labels)
+		if instanceLabels == nil {
+			instanceLabels = map[string]string{}
+		}
+		instanceLabels[metalv1alpha1.IgnitionV3SetLabel] = set.Name
+		instanceLabels[metalv1alpha1.IgnitionV3SetInstanceLabel] = instance.Name
+		applyMetadata(ignition, instanceLabels, nil)
+
+		ignition.Spec = *set.Spec.Template.Spec.DeepCopy()
+		ignition.Spec.TargetSecret.Name = strings.ReplaceAll(ignition.Spec.TargetSecret.Name, instancePlaceholder, instance.Name)
+		for i := range ignition.Spec.Outputs {
+			if output := &ignition.Spec.Outputs[i]; output.Secret != nil {
+				output.Secret.Name = strings.ReplaceAll(output.Secret.Name, instancePlaceholder, instance.Name)
+			} else if output.ConfigMap != nil {
+				output.ConfigMap.Name = strings.ReplaceAll(output.ConfigMap.Name, instancePlaceholder, instance.Name)
+			}
+		}
+		if ignition.Spec.Variables == nil {
+			ignition.Spec.Variables = map[string]string{}
+		}
+		maps.Copy(ignition.Spec.Variables, instance.Variables)
+		ignition.Spec.Variables["instance"] = instance.Name
+		return controllerutil.SetControllerReference(set, ignition, r.Scheme)
+	})
+	return err
+}
+
+// deleteRemovedInstances deletes the IgnitionV3 objects generated by the set for instances which aren't part of it anymore.
+func (r *IgnitionV3SetReconciler) deleteRemovedInstances(ctx context.Context, set *metalv1alpha1.IgnitionV3Set, instances []metalv1alpha1.IgnitionV3SetInstance) error {
+	ignitionList := &metalv1alpha1.IgnitionV3List{}
+	if err := r.List(ctx, ignitionList, client.InNamespace(set.Namespace), client.MatchingLabels{metalv1alpha1.IgnitionV3SetLabel: set.Name}); err != nil {
+		return err
+	}
+	for _, ignition := range ignitionList.Items {
+		if !metav1.IsControlledBy(&ignition, set) {
+			continue
+		}
+		isInstance := slices.ContainsFunc(instances, func(instance metalv1alpha1.IgnitionV3SetInstance) bool {
+			return ignition.Name == instanceIgnitionName(set, instance.Name)
+		})
+		if isInstance {
+			continue
+		}
+		if err := r.Delete(ctx, &ignition); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *IgnitionV3SetReconciler) patchInstancesStatus(ctx context.Context, set *metalv1alpha1.IgnitionV3Set, count int, notControlled []string, instancesErr error) error {
+	condition := metav1.Condition{
+		Type:               metalv1alpha1.InstancesType,
+		LastTransitionTime: metav1.Now(),
+		Status:             metav1.ConditionTrue,
+		Reason:             "InstancesReconciled",
+		Message:            fmt.Sprintf("%d IgnitionV3 objects are generated", count),
+	}
+	if len(notControlled) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InstancesNotControlled"
+		condition.Message = fmt.Sprintf("%d IgnitionV3 objects are generated, %s %s exist without being controlled by the set",
+			count, metalv1alpha1.IgnitionV3Kind, strings.Join(notControlled, ", "))
+	}
+	if instancesErr != nil {
+		// the generated ignitions are kept until the instances can be determined again
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InstancesFailed"
+		condition.Message = instancesErr.Error()
+		count = int(set.Status.Instances)
+		var invalidErr *invalidInstancesError
+		if errors.As(instancesErr, &invalidErr) {
+			condition.Reason = invalidErr.reason
+		}
+	}
+
+	setBase := set.DeepCopy()
+	changed := meta.SetStatusCondition(&set.Status.Conditions, condition)
+	if set.Status.Instances != int32(count) {
+		set.Status.Instances = int32(count)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	if err := r.Status().Patch(ctx, set, client.MergeFrom(setBase)); err != nil {
+		return fmt.Errorf("failed to patch IgnitionV3Set status: %w", err)
+	}
+	return nil
+}
+
+// instanceIgnitionName returns the name of the IgnitionV3 generated for an instance.
+func instanceIgnitionName(set *metalv1alpha1.IgnitionV3Set, instance string) string {
+	return fmt.Sprintf("%s-%s", set.Name, instance)
+}
+
+// configMapToIgnitionSets maps a ConfigMap to the IgnitionV3Set objects which read their instances from it.
+func (r *IgnitionV3SetReconciler) configMapToIgnitionSets(ctx context.Context, obj client.Object) []reconcile.Request {
+	setList := &metalv1alpha1.IgnitionV3SetList{}
+	if err := r.List(ctx, setList, client.InNamespace(obj.GetNamespace())); err != nil {
+		ctrllog.FromContext(ctx).Error(err, "couldn't list ignition sets")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, set := range setList.Items {
+		if set.Spec.InstancesFrom != nil && set.Spec.InstancesFrom.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&set)})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *IgnitionV3SetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&metalv1alpha1.IgnitionV3Set{}).
+		Owns(&metalv1alpha1.IgnitionV3{}).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.configMapToIgnitionSets)).
+		Named("ignitionv3set").
+		Complete(r)
+}
@@ -0,0 +1,222 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("IgnitionV3Set Controller", func() {
+	Context("When reconciling a resource", func() {
+		const (
+			name                   = "test-ignition-set"
+			instancesConfigMapName = "test-instances-configmap"
+		)
+
+		var (
+			set                *metalv1alpha1.IgnitionV3Set
+			instancesConfigMap *corev1.ConfigMap
+			nn                 = types.NamespacedName{Name: name, Namespace: namespace}
+
+			listGenerated = func() []metalv1alpha1.IgnitionV3 {
+				ignitionList := &metalv1alpha1.IgnitionV3List{}
+				Expect(k8sClient.List(ctx, ignitionList, client.InNamespace(namespace), client.MatchingLabels{metalv1alpha1.IgnitionV3SetLabel: name})).To(Succeed())
+				return ignitionList.Items
+			}
+		)
+
+		BeforeEach(func() {
+			set = &metalv1alpha1.IgnitionV3Set{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+			set.Spec.Template.Labels = map[string]string{"role": "worker"}
+			set.Spec.Template.Spec.Ignition.Version = "3.5.0"
+			set.Spec.Template.Spec.TargetSecret = &metalv1alpha1.TargetSecret{Name: "worker-${instance}"}
+			set.Spec.Template.Spec.KernelArguments.ShouldExist = []metalv1alpha1.KernelArgument{"rack=${rack}"}
+			set.Spec.Template.Spec.Variables = map[string]string{"rack": "default"}
+			set.Spec.Instances = []metalv1alpha1.IgnitionV3SetInstance{
+				{Name: "node-1", Variables: map[string]string{"rack": "r1"}},
+				{Name: "node-2"},
+			}
+			instancesConfigMap = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: instancesConfigMapName, Namespace: namespace}}
+			instancesConfigMap.Data = map[string]string{"node-3": "rack: r3\n"}
+		})
+
+		AfterEach(func() {
+			for _, ignition := range listGenerated() {
+				if controllerutil.RemoveFinalizer(&ignition, finalizer) {
+					Expect(k8sClient.Update(ctx, &ignition)).To(Succeed())
+				}
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &ignition))).To(Succeed())
+			}
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, set))).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, instancesConfigMap))).To(Succeed())
+		})
+
+		It("should generate an IgnitionV3 per instance", func() {
+			Expect(k8sClient.Create(ctx, set)).To(Succeed())
+
+			controller := &IgnitionV3SetReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			ignition := &metalv1alpha1.IgnitionV3{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name + "-node-1", Namespace: namespace}, ignition)).To(Succeed())
+			Expect(ignition.Labels).To(HaveKeyWithValue("role", "worker"))
+			Expect(ignition.Spec.TargetSecret.Name).To(Equal("worker-node-1"))
+			Expect(ignition.Spec.Variables).To(Equal(map[string]string{"instance": "node-1", "rack": "r1"}))
+			Expect(metav1.IsControlledBy(ignition, set)).To(BeTrue())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name + "-node-2", Namespace: namespace}, ignition)).To(Succeed())
+			Expect(ignition.Spec.Variables).To(Equal(map[string]string{"instance": "node-2", "rack": "default"}))
+
+			Expect(k8sClient.Get(ctx, nn, set)).To(Succeed())
+			Expect(set.Status.Instances).To(Equal(int32(2)))
+		})
+
+		It("when an instance defines the variable instance, should keep it set to the instance name", func() {
+			set.Spec.Instances[0].Variables["instance"] = "other"
+			Expect(k8sClient.Create(ctx, set)).To(Succeed())
+
+			controller := &IgnitionV3SetReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			ignition := &metalv1alpha1.IgnitionV3{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name + "-node-1", Namespace: namespace}, ignition)).To(Succeed())
+			Expect(ignition.Spec.Variables).To(HaveKeyWithValue("instance", "node-1"))
+		})
+
+		It("when a label is removed from the template, should remove it from the generated IgnitionV3", func() {
+			Expect(k8sClient.Create(ctx, set)).To(Succeed())
+
+			controller := &IgnitionV3SetReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, nn, set)).To(Succeed())
+			set.Spec.Template.Labels = nil
+			Expect(k8sClient.Update(ctx, set)).To(Succeed())
+			_, err = controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			ignition := &metalv1alpha1.IgnitionV3{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name + "-node-1", Namespace: namespace}, ignition)).To(Succeed())
+			Expect(ignition.Labels).NotTo(HaveKey("role"))
+			Expect(ignition.Labels).To(HaveKeyWithValue(metalv1alpha1.IgnitionV3SetLabel, name))
+		})
+
+		It("when the template has outputs, should replace the instance placeholder in their names", func() {
+			set.Spec.Template.Spec.Outputs = []metalv1alpha1.IgnitionV3Output{
+				{Name: "secret", Secret: &metalv1alpha1.TargetSecret{Name: "worker-${instance}-user-data"}},
+				{Name: "configmap", ConfigMap: &metalv1alpha1.TargetConfigMap{Name: "worker-${instance}-ignition"}},
+			}
+			Expect(k8sClient.Create(ctx, set)).To(Succeed())
+
+			controller := &IgnitionV3SetReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			ignition := &metalv1alpha1.IgnitionV3{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name + "-node-1", Namespace: namespace}, ignition)).To(Succeed())
+			Expect(ignition.Spec.Outputs[0].Secret.Name).To(Equal("worker-node-1-user-data"))
+			Expect(ignition.Spec.Outputs[1].ConfigMap.Name).To(Equal("worker-node-1-ignition"))
+		})
+
+		It("when an instance is removed, should delete its IgnitionV3", func() {
+			Expect(k8sClient.Create(ctx, set)).To(Succeed())
+
+			controller := &IgnitionV3SetReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(listGenerated()).To(HaveLen(2))
+
+			set.Spec.Instances = set.Spec.Instances[:1]
+			Expect(k8sClient.Update(ctx, set)).To(Succeed())
+			_, err = controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			ignition := &metalv1alpha1.IgnitionV3{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name + "-node-1", Namespace: namespace}, ignition)).To(Succeed())
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: name + "-node-2", Namespace: namespace}, ignition)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("when instances are read from a ConfigMap, should generate an IgnitionV3 per key", func() {
+			set.Spec.InstancesFrom = &corev1.LocalObjectReference{Name: instancesConfigMapName}
+			Expect(k8sClient.Create(ctx, set)).To(Succeed())
+			Expect(k8sClient.Create(ctx, instancesConfigMap)).To(Succeed())
+
+			controller := &IgnitionV3SetReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			Expect(controller.configMapToIgnitionSets(ctx, instancesConfigMap)).To(ConsistOf(reconcile.Request{NamespacedName: nn}))
+
+			_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			ignition := &metalv1alpha1.IgnitionV3{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name + "-node-3", Namespace: namespace}, ignition)).To(Succeed())
+			Expect(ignition.Spec.Variables).To(Equal(map[string]string{"instance": "node-3", "rack": "r3"}))
+		})
+
+		It("when the instances ConfigMap doesn't exist, should update the status to false and keep the generated IgnitionV3", func() {
+			Expect(k8sClient.Create(ctx, set)).To(Succeed())
+
+			controller := &IgnitionV3SetReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, nn, set)).To(Succeed())
+			set.Spec.InstancesFrom = &corev1.LocalObjectReference{Name: instancesConfigMapName}
+			Expect(k8sClient.Update(ctx, set)).To(Succeed())
+			_, err = controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, nn, set)).To(Succeed())
+			condition := meta.FindStatusCondition(set.Status.Conditions, metalv1alpha1.InstancesType)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal("ReferencesMissing"))
+			Expect(listGenerated()).To(HaveLen(2))
+		})
+
+		It("when the IgnitionV3 of an instance exists without being controlled by the set, should keep it and update the status to false", func() {
+			other := &metalv1alpha1.IgnitionV3{ObjectMeta: metav1.ObjectMeta{Name: name + "-node-2", Namespace: namespace}}
+			other.Spec.Ignition.Version = "3.5.0"
+			Expect(k8sClient.Create(ctx, other)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, other))).To(Succeed())
+			})
+			Expect(k8sClient.Create(ctx, set)).To(Succeed())
+
+			controller := &IgnitionV3SetReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: nn})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(other), other)).To(Succeed())
+			Expect(other.Spec.TargetSecret).To(BeNil())
+			Expect(other.OwnerReferences).To(BeEmpty())
+
+			Expect(k8sClient.Get(ctx, nn, set)).To(Succeed())
+			Expect(set.Status.Instances).To(Equal(int32(1)))
+			condition := meta.FindStatusCondition(set.Status.Conditions, metalv1alpha1.InstancesType)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal("InstancesNotControlled"))
+			Expect(condition.Message).To(ContainSubstring(name + "-node-2"))
+		})
+
+		It("when the target secret name doesn't contain the instance placeholder, should be rejected", func() {
+			set.Spec.Template.Spec.TargetSecret.Name = "worker"
+			Expect(k8sClient.Create(ctx, set)).NotTo(Succeed())
+		})
+	})
+})
@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/coreos/ignition/v2/config/merge"
+	ignitiontypes "github.com/coreos/ignition/v2/config/v3_5/types"
+	vpath "github.com/coreos/vcontext/path"
+
+	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
+)
+
+// kernelArgumentKind is the kind of the conflicts of kernel arguments required by one merged object and forbidden
+// by another.
+const kernelArgumentKind = "kernel argument"
+
+// kernelArgumentContradiction is a kernel argument both required and forbidden by the merged config.
+type kernelArgumentContradiction struct {
+	arg         string
+	requiredBy  string
+	forbiddenBy string
+}
+
+// kernelArgumentsContradictionError is returned when substituting variables makes the merged config of a target
+// ignition require and forbid the same kernel argument.
+type kernelArgumentsContradictionError struct {
+	contradictions []kernelArgumentContradiction
+}
+
+func (e *kernelArgumentsContradictionError) Error() string {
+	messages := make([]string, 0, len(e.contradictions))
+	for _, contradiction := range e.contradictions {
+		messages = append(messages, fmt.Sprintf("%s required by %s and forbidden by %s", contradiction.arg, contradiction.requiredBy, contradiction.forbiddenBy))
+	}
+	return fmt.Sprintf("kernel arguments are both required and forbidden once variables are substituted: %s", strings.Join(messages, "; "))
+}
+
+func (e *kernelArgumentsContradictionError) reason() string {
+	return "KernelArgumentsContradictory"
+}
+
+// detectContradictions returns the kernel arguments required by parent and forbidden by child or the other way round
+// as conflicts. As merging drops the contradicting argument of the parent, it has to be called before they are merged.
+func detectContradictions(parent ignitiontypes.Config, parentProvenance provenance, child ignitiontypes.Config, childProvenance provenance) []mergeConflict {
+	var conflicts []mergeConflict
+	add := func(parentList, childList string, parentArgs, childArgs []ignitiontypes.KernelArgument) {
+		for i, arg := range parentArgs {
+			if j := slices.Index(childArgs, arg); j >= 0 {
+				conflicts = append(conflicts, mergeConflict{kind: kernelArgumentKind, key: string(arg), origins: []string{
+					parentProvenance[kernelArgumentPath(parentList, i)],
+					childProvenance[kernelArgumentPath(childList, j)],
+				}})
+			}
+		}
+	}
+	add("shouldExist", "shouldNotExist", parent.KernelArguments.ShouldExist, child.KernelArguments.ShouldNotExist)
+	add("shouldNotExist", "shouldExist", parent.KernelArguments.ShouldNotExist, child.KernelArguments.ShouldExist)
+	return conflicts
+}
+
+// mergedContradictions returns the contradicting kernel arguments of merged objects when the conflict policy merges
+// them like any other merge, so the argument of the object merged last takes effect.
+func (s *mergeState) mergedContradictions() []mergeConflict {
+	if s.conflictPolicy != "" && s.conflictPolicy != metalv1alpha1.ConflictPolicyWarn {
+		return nil
+	}
+	var contradictions []mergeConflict
+	for _, conflict := range s.conflicts {
+		if conflict.kind == kernelArgumentKind {
+			contradictions = append(contradictions, conflict)
+		}
+	}
+	return contradictions
+}
+
+// contradictionsMessage describes the contradicting kernel arguments sorted by their argument.
+func contradictionsMessage(contradictions []mergeConflict) string {
+	sorted := slices.SortedFunc(slices.Values(contradictions), func(a, b mergeConflict) int { return cmp.Compare(a.key, b.key) })
+	messages := make([]string, 0, len(sorted))
+	for _, contradiction := range sorted {
+		messages = append(messages, fmt.Sprintf("%s (%s)", contradiction.key, strings.Join(contradiction.origins, ", ")))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// checkKernelArguments drops repeated kernel arguments of config, keeping their first occurrence, and records
+// the kernel arguments config requires and forbids at once. Merging doesn't leave either in config, but substituting
+// variables can. The dropped arguments are returned.
+func (s *mergeState) checkKernelArguments(config ignitiontypes.Config, configProvenance provenance) (ignitiontypes.Config, []string) {
+	var repeated []string
+	config.KernelArguments.ShouldExist, repeated = dedupeKernelArguments(config.KernelArguments.ShouldExist, configProvenance, "shouldExist")
+	var repeatedForbidden []string
+	config.KernelArguments.ShouldNotExist, repeatedForbidden = dedupeKernelArguments(config.KernelArguments.ShouldNotExist, configProvenance, "shouldNotExist")
+	repeated = append(repeated, repeatedForbidden...)
+
+	for i, required := range config.KernelArguments.ShouldExist {
+		if j := slices.Index(config.KernelArguments.ShouldNotExist, required); j >= 0 {
+			s.contradictions = append(s.contradictions, kernelArgumentContradiction{
+				arg:         string(required),
+				requiredBy:  configProvenance[kernelArgumentPath("shouldExist", i)],
+				forbiddenBy: configProvenance[kernelArgumentPath("shouldNotExist", j)],
+			})
+		}
+	}
+	return config, repeated
+}
+
+// dedupeKernelArguments drops the repeated arguments of args and moves the provenance of the kept ones to their new index.
+func dedupeKernelArguments(args []ignitiontypes.KernelArgument, configProvenance provenance, list string) ([]ignitiontypes.KernelArgument, []string) {
+	var repeated []string
+	deduped := filterKernelArguments(args, configProvenance, list, func(kept []ignitiontypes.KernelArgument, arg ignitiontypes.KernelArgument) bool {
+		if slices.Contains(kept, arg) {
+			repeated = append(repeated, string(arg))
+			return true
+		}
+		return false
+	})
+	return deduped, repeated
+}
+
+// filterKernelArguments drops the arguments of args drop returns true for, given the arguments kept before them,
+// and moves the provenance of the kept ones to their new index.
+func filterKernelArguments(args []ignitiontypes.KernelArgument, configProvenance provenance, list string, drop func(kept []ignitiontypes.KernelArgument, arg ignitiontypes.KernelArgument) bool) []ignitiontypes.KernelArgument {
+	var kept []ignitiontypes.KernelArgument
+	origins := make([]string, 0, len(args))
+	for i, arg := range args {
+		origin := configProvenance[kernelArgumentPath(list, i)]
+		delete(configProvenance, kernelArgumentPath(list, i))
+		if drop(kept, arg) {
+			continue
+		}
+		kept = append(kept, arg)
+		origins = append(origins, origin)
+	}
+	for i, origin := range origins {
+		if origin != "" {
+			configProvenance[kernelArgumentPath(list, i)] = origin
+		}
+	}
+	return kept
+}
+
+// kernelArgumentPath returns the path of the i-th kernel argument of list, which is shouldExist or shouldNotExist.
+func kernelArgumentPath(list string, i int) string {
+	return vpath.New(merge.TAG_RESULT, "kernelArguments", list, i).String()
+}
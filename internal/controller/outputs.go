@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	ignitiontypes "github.com/coreos/ignition/v2/config/v3_5/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
+)
+
+// reconcileOutputs renders mergedConfig for each output of a target ignition and writes it to the output's Secret or
+// ConfigMap. An output whose configuration can't be rendered is reported in the status without affecting the others.
+// ConfigMap outputs aren't written when mergedConfig contains data of the secretSources, so credentials only ever
+// land in Secrets. Objects which exist without being owned by the ignition aren't written either. The objects of outputs
+// removed from the spec are deleted.
+func (r *IgnitionV3Reconciler) reconcileOutputs(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, mergedConfig ignitiontypes.Config, secretSources []string) error {
+	if err := r.deleteRemovedOutputs(ctx, ignition); err != nil {
+		return err
+	}
+
+	var statuses []metalv1alpha1.OutputStatus
+	for _, output := range ignition.Spec.Outputs {
+		status, err := r.reconcileOutput(ctx, ignition, output, mergedConfig, secretSources)
+		if err != nil {
+			return fmt.Errorf("couldn't reconcile output %s: %w", output.Name, err)
+		}
+		statuses = append(statuses, status)
+	}
+	return r.patchOutputsStatus(ctx, ignition, statuses)
+}
+
+// deleteRemovedOutputs deletes the Secrets and ConfigMaps owned by ignition which are neither the object of one of its
+// outputs nor its target, rendered Secret or provenance ConfigMap.
+func (r *IgnitionV3Reconciler) deleteRemovedOutputs(ctx context.Context, ignition *metalv1alpha1.IgnitionV3) error {
+	secretNames := []string{ignition.Spec.TargetSecret.Name, ignition.Name + metalv1alpha1.RenderedSecretSuffix}
+	configMapNames := []string{ignition.Name + metalv1alpha1.ProvenanceConfigMapSuffix}
+	for _, output := range ignition.Spec.Outputs {
+		if output.Secret != nil {
+			secretNames = append(secretNames, output.Secret.Name)
+		} else {
+			configMapNames = append(configMapNames, output.ConfigMap.Name)
+		}
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := r.List(ctx, secrets, client.InNamespace(ignition.Namespace)); err != nil {
+		return fmt.Errorf("couldn't list secrets. Reason: %v", err)
+	}
+	for i := range secrets.Items {
+		if err := r.deleteRemovedOutput(ctx, ignition, &secrets.Items[i], secretNames); err != nil {
+			return err
+		}
+	}
+
+	configMaps := &corev1.ConfigMapList{}
+	if err := r.List(ctx, configMaps, client.InNamespace(ignition.Namespace)); err != nil {
+		return fmt.Errorf("couldn't list config maps. Reason: %v", err)
+	}
+	for i := range configMaps.Items {
+		if err := r.deleteRemovedOutput(ctx, ignition, &configMaps.Items[i], configMapNames); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *IgnitionV3Reconciler) deleteRemovedOutput(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, obj client.Object, names []string) error {
+	if slices.Contains(names, obj.GetName()) || !isOwnedBy(obj, ignition) {
+		return nil
+	}
+	if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("couldn't delete removed output %s. Reason: %v", obj.GetName(), err)
+	}
+	return nil
+}
+
+func (r *IgnitionV3Reconciler) reconcileOutput(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, output metalv1alpha1.IgnitionV3Output, mergedConfig ignitiontypes.Config, secretSources []string) (metalv1alpha1.OutputStatus, error) {
+	status := metalv1alpha1.OutputStatus{Name: output.Name}
+	if output.ConfigMap != nil && len(secretSources) > 0 {
+		status.Message = fmt.Sprintf("merged configuration contains data of %s %s and can only be written to Secret outputs",
+			metalv1alpha1.SecretKind, strings.Join(secretSources, ", "))
+		return status, nil
+	}
+
+	var extraData map[string]string
+	if output.Secret != nil {
+		extraData = output.Secret.ExtraData
+	} else {
+		extraData = output.ConfigMap.ExtraData
+	}
+
+	version := cmp.Or(output.OutputVersion, ignition.Spec.OutputVersion)
+	configBytes, err := render(mergedConfig, version)
+	storedConfigBytes, strategy := configBytes, metalv1alpha1.OutputStrategyPlain
+	if err == nil {
+		storedConfigBytes, strategy, err = fitSecretSize(configBytes, version, dataSizeLimit(extraData))
+	}
+	var configErr configurationError
+	if errors.As(err, &configErr) {
+		status.Message = err.Error()
+		return status, nil
+	}
+	if err != nil {
+		return status, err
+	}
+
+	if output.Secret != nil {
+		err = r.reconcileOutputSecret(ctx, ignition, output.Secret, storedConfigBytes)
+	} else {
+		err = r.reconcileOutputConfigMap(ctx, ignition, output.ConfigMap, storedConfigBytes)
+	}
+	var notOwnedErr *objectNotOwnedError
+	if errors.As(err, &notOwnedErr) {
+		status.Message = err.Error()
+		return status, nil
+	}
+	if err != nil {
+		return status, err
+	}
+	status.Ready = true
+	status.RenderedSize = int64(len(configBytes))
+	status.OutputStrategy = strategy
+	return status, nil
+}
+
+func (r *IgnitionV3Reconciler) reconcileOutputSecret(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, target *metalv1alpha1.TargetSecret, configBytes []byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      target.Name,
+			Namespace: ignition.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrPatch(ctx, r.Client, secret, func() error {
+		if err := checkOwned(secret, ignition); err != nil {
+			return err
+		}
+		applyTargetSecret(secret, target, configBytes, nil)
+		return controllerutil.SetOwnerReference(ignition, secret, r.Scheme)
+	})
+	return err
+}
+
+func (r *IgnitionV3Reconciler) reconcileOutputConfigMap(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, target *metalv1alpha1.TargetConfigMap, configBytes []byte) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      target.Name,
+			Namespace: ignition.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrPatch(ctx, r.Client, configMap, func() error {
+		if err := checkOwned(configMap, ignition); err != nil {
+			return err
+		}
+		data := map[string]string{cmp.Or(target.Key, secretConfigData): string(configBytes)}
+		maps.Copy(data, target.ExtraData)
+		configMap.Data = applyManaged(configMap.Data, data, managedKeys(configMap, metalv1alpha1.ManagedDataAnnotation))
+		recordManagedKeys(configMap, metalv1alpha1.ManagedDataAnnotation, data)
+		applyMetadata(configMap, target.Labels, target.Annotations)
+		return controllerutil.SetOwnerReference(ignition, configMap, r.Scheme)
+	})
+	return err
+}
+
+func (r *IgnitionV3Reconciler) patchOutputsStatus(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, statuses []metalv1alpha1.OutputStatus) error {
+	if slices.Equal(ignition.Status.Outputs, statuses) {
+		return nil
+	}
+	ignitionBase := ignition.DeepCopy()
+	ignition.Status.Outputs = statuses
+	return r.Status().Patch(ctx, ignition, client.MergeFrom(ignitionBase))
+}
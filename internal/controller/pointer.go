@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	ignitiontypes "github.com/coreos/ignition/v2/config/v3_5/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
+)
+
+// renderPointerConfig renders a pointer config replacing itself with the merged configuration served by
+// the ignition HTTP server. The merged configuration is verified with its hash. A token Secret which
+// doesn't exist is recorded in state.
+func (r *IgnitionV3Reconciler) renderPointerConfig(ctx context.Context, ign *metalv1alpha1.IgnitionV3, configBytes []byte, state *mergeState) ([]byte, error) {
+	// the token isn't part of the merged configuration, so it isn't recorded as a Secret source
+	ref := newConfigSourceRef(ign.Namespace, metalv1alpha1.ConfigSource{SecretKeyRef: ign.Spec.TokenFrom})
+	token, found, err := r.readConfigSource(ctx, ref)
+	if err == nil && !found && !ref.optional {
+		state.missingSources = append(state.missingSources, ref.String())
+	}
+	if err != nil || !found {
+		return nil, err
+	}
+
+	source, err := url.JoinPath(ign.Spec.Pointer.URL, "ignition", ign.Namespace, ign.Name)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't build pointer URL. Reason: %v", err)
+	}
+	hash := sha512.Sum512(configBytes)
+
+	pointer := ignitiontypes.Config{}
+	pointer.Ignition.Version = ignitiontypes.MaxVersion.String()
+	pointer.Ignition.Config.Replace = ignitiontypes.Resource{
+		Source: ptr.To(source),
+		HTTPHeaders: ignitiontypes.HTTPHeaders{
+			{Name: "Authorization", Value: ptr.To("Bearer " + strings.TrimSpace(string(token)))},
+		},
+		Verification: ignitiontypes.Verification{Hash: ptr.To("sha512-" + hex.EncodeToString(hash[:]))},
+	}
+	return render(pointer, ign.Spec.OutputVersion)
+}
+
+// reconcileRenderedSecret writes the merged configuration of a target ignition using a pointer config
+// to the Secret the ignition HTTP server serves it from.
+func (r *IgnitionV3Reconciler) reconcileRenderedSecret(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, configBytes []byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ignition.Name + metalv1alpha1.RenderedSecretSuffix,
+			Namespace: ignition.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrPatch(ctx, r.Client, secret, func() error {
+		if err := checkOwned(secret, ignition); err != nil {
+			return err
+		}
+		secret.Data = map[string][]byte{secretConfigData: configBytes}
+		return controllerutil.SetOwnerReference(ignition, secret, r.Scheme)
+	})
+	return err
+}
+
+// deleteRenderedSecret deletes the Secret written by reconcileRenderedSecret once the ignition stops using a pointer config.
+func (r *IgnitionV3Reconciler) deleteRenderedSecret(ctx context.Context, ignition *metalv1alpha1.IgnitionV3) error {
+	secret := &corev1.Secret{}
+	nn := types.NamespacedName{Namespace: ignition.Namespace, Name: ignition.Name + metalv1alpha1.RenderedSecretSuffix}
+	if err := r.Get(ctx, nn, secret); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !isOwnedBy(secret, ignition) {
+		return nil
+	}
+	return client.IgnoreNotFound(r.Delete(ctx, secret))
+}
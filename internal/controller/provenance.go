@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	"github.com/coreos/ignition/v2/config/merge"
+	ignitiontypes "github.com/coreos/ignition/v2/config/v3_5/types"
+	vpath "github.com/coreos/vcontext/path"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
+)
+
+// provenance maps the paths of the fields of a config to the merged object which set them, e.g. $.storage.files.0.path
+// to IgnitionV3/default/chrony. Fields with contributions of several objects are attributed to the last one merged.
+type provenance map[string]string
+
+// newProvenance attributes all fields of config to origin.
+func newProvenance(config ignitiontypes.Config, origin string) provenance {
+	_, transcript := merge.MergeStructTranscribe(ignitiontypes.Config{}, config)
+	p := provenance{}
+	for _, mapping := range transcript.Mappings {
+		p[mapping.To.String()] = origin
+	}
+	return p
+}
+
+// mergeWithProvenance merges child into parent like ignitionConfig.Merge and follows the merge transcript
+// to compute the provenance of the result from the provenance of parent and child.
+func mergeWithProvenance(parent ignitiontypes.Config, parentProvenance provenance, child ignitiontypes.Config, childProvenance provenance) (ignitiontypes.Config, provenance) {
+	result, transcript := merge.MergeStructTranscribe(parent, child)
+	p := provenance{}
+	// the transcript lists the mappings of the parent before the ones of the child, so the child wins
+	for _, mapping := range transcript.Mappings {
+		from := parentProvenance
+		if mapping.From.Tag == merge.TAG_CHILD {
+			from = childProvenance
+		}
+		if origin, ok := from[mapping.From.String()]; ok {
+			p[mapping.To.String()] = origin
+		}
+	}
+	return result.(ignitiontypes.Config), p
+}
+
+// originOf returns the origin of the fields set by a merged ignition.
+func originOf(ignition metalv1alpha1.MergedIgnition) string {
+	return path.Join(ignition.Kind, ignition.Namespace, ignition.Name)
+}
+
+// provenanceSummary maps the keys of the merged config, grouped by their kind, to the objects which set them.
+type provenanceSummary map[string]map[string]string
+
+// summarize returns the objects which set the files, directories, links, storage devices, units, dropins, users,
+// groups and kernel arguments of config.
+func (p provenance) summarize(config ignitiontypes.Config) provenanceSummary {
+	summary := provenanceSummary{}
+	add := func(kind, key string, fieldPath ...any) {
+		origin, ok := p[vpath.New(merge.TAG_RESULT, fieldPath...).String()]
+		if !ok {
+			return
+		}
+		if summary[kind] == nil {
+			summary[kind] = map[string]string{}
+		}
+		summary[kind][key] = origin
+	}
+
+	for i, file := range config.Storage.Files {
+		add("files", file.Path, "storage", "files", i)
+	}
+	for i, directory := range config.Storage.Directories {
+		add("directories", directory.Path, "storage", "directories", i)
+	}
+	for i, link := range config.Storage.Links {
+		add("links", link.Path, "storage", "links", i)
+	}
+	for i, disk := range config.Storage.Disks {
+		add("disks", disk.Device, "storage", "disks", i)
+	}
+	for i, raid := range config.Storage.Raid {
+		add("raid", raid.Name, "storage", "raid", i)
+	}
+	for i, filesystem := range config.Storage.Filesystems {
+		add("filesystems", filesystem.Device, "storage", "filesystems", i)
+	}
+	for i, luks := range config.Storage.Luks {
+		add("luks", luks.Name, "storage", "luks", i)
+	}
+	for i, unit := range config.Systemd.Units {
+		add("units", unit.Name, "systemd", "units", i)
+		for j, dropin := range unit.Dropins {
+			add("dropins", unit.Name+"/"+dropin.Name, "systemd", "units", i, "dropins", j)
+		}
+	}
+	for i, user := range config.Passwd.Users {
+		add("users", user.Name, "passwd", "users", i)
+	}
+	for i, group := range config.Passwd.Groups {
+		add("groups", group.Name, "passwd", "groups", i)
+	}
+	for i, arg := range config.KernelArguments.ShouldExist {
+		add("kernelArguments", string(arg), "kernelArguments", "shouldExist", i)
+	}
+	for i, arg := range config.KernelArguments.ShouldNotExist {
+		add("kernelArgumentsNotExisting", string(arg), "kernelArguments", "shouldNotExist", i)
+	}
+	return summary
+}
+
+// countKeys sets the number of keys each merged ignition of order set in the summary.
+func (s provenanceSummary) countKeys(order []metalv1alpha1.MergedIgnition) {
+	counts := map[string]int32{}
+	for _, keys := range s {
+		for _, origin := range keys {
+			counts[origin]++
+		}
+	}
+	for i := range order {
+		order[i].Keys = counts[originOf(order[i])]
+	}
+}
+
+// reconcileProvenanceConfigMap writes the provenance summary of the merged configuration of a target ignition
+// to the ConfigMap next to its TargetSecret.
+func (r *IgnitionV3Reconciler) reconcileProvenanceConfigMap(ctx context.Context, ignition *metalv1alpha1.IgnitionV3, summary provenanceSummary) error {
+	summaryBytes, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal provenance. Reason: %v", err)
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ignition.Name + metalv1alpha1.ProvenanceConfigMapSuffix,
+			Namespace: ignition.Namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrPatch(ctx, r.Client, configMap, func() error {
+		if err := checkOwned(configMap, ignition); err != nil {
+			return err
+		}
+		configMap.Data = map[string]string{metalv1alpha1.ProvenanceConfigMapKey: string(summaryBytes)}
+		return controllerutil.SetOwnerReference(ignition, configMap, r.Scheme)
+	})
+	return err
+}
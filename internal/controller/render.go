@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	v3_0 "github.com/coreos/ignition/v2/config/v3_0"
+	v3_1 "github.com/coreos/ignition/v2/config/v3_1"
+	v3_2 "github.com/coreos/ignition/v2/config/v3_2"
+	v3_3 "github.com/coreos/ignition/v2/config/v3_3"
+	v3_4 "github.com/coreos/ignition/v2/config/v3_4"
+	ignitiontypes "github.com/coreos/ignition/v2/config/v3_5/types"
+	"github.com/coreos/vcontext/report"
+)
+
+// parsers holds the parsers of the ignition specification versions a merged config can be rendered in,
+// apart from the newest one.
+var parsers = map[string]func([]byte) (any, report.Report, error){
+	"3.0.0": func(raw []byte) (any, report.Report, error) { return v3_0.Parse(raw) },
+	"3.1.0": func(raw []byte) (any, report.Report, error) { return v3_1.Parse(raw) },
+	"3.2.0": func(raw []byte) (any, report.Report, error) { return v3_2.Parse(raw) },
+	"3.3.0": func(raw []byte) (any, report.Report, error) { return v3_3.Parse(raw) },
+	"3.4.0": func(raw []byte) (any, report.Report, error) { return v3_4.Parse(raw) },
+}
+
+// unsupportedFieldsError is returned when a merged config uses fields which can't be represented
+// in the requested ignition specification version.
+type unsupportedFieldsError struct {
+	version string
+	fields  []string
+}
+
+func (e *unsupportedFieldsError) Error() string {
+	return fmt.Sprintf("merged configuration can't be rendered in ignition version %s, unsupported fields: %s",
+		e.version, strings.Join(e.fields, ", "))
+}
+
+func (e *unsupportedFieldsError) reason() string {
+	return "OutputVersionUnsupported"
+}
+
+// render marshals config in the given ignition specification version. The newest version is used when version is empty.
+// Older versions are rendered by parsing the config with the parser of that version.
+func render(config ignitiontypes.Config, version string) ([]byte, error) {
+	if version == "" || version == ignitiontypes.MaxVersion.String() {
+		return json.Marshal(config)
+	}
+
+	parse, ok := parsers[version]
+	if !ok {
+		return nil, fmt.Errorf("ignition version %s isn't supported", version)
+	}
+
+	config.Ignition.Version = version
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't marshal merged configuration. Reason: %v", err)
+	}
+	if configBytes, err = pruneEmptyFields(configBytes); err != nil {
+		return nil, fmt.Errorf("couldn't prune merged configuration. Reason: %v", err)
+	}
+
+	cfg, rpt, err := parse(configBytes)
+	if unusedKeys := unusedKeys(rpt); len(unusedKeys) > 0 {
+		return nil, &unsupportedFieldsError{version: version, fields: unusedKeys}
+	}
+	if rpt.IsFatal() {
+		// the fields exist in the requested version, but their values are invalid there
+		invalidFields := []string{}
+		for _, entry := range rpt.Entries {
+			if entry.Kind == report.Error {
+				invalidFields = append(invalidFields, fmt.Sprintf("%s (%s)", entry.Context.String(), entry.Message))
+			}
+		}
+		return nil, &unsupportedFieldsError{version: version, fields: invalidFields}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse merged configuration in ignition version %s. Reason: %v", version, err)
+	}
+	return json.Marshal(cfg)
+}
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+
+	ignitiontypes "github.com/coreos/ignition/v2/config/v3_5/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
+)
+
+// maxSecretDataSize is the maximum size of the data of a Secret accepted by the API server.
+var maxSecretDataSize = corev1.MaxSecretSize
+
+// secretTooLargeError is returned when a merged config exceeds the Secret size limit even when compressed.
+type secretTooLargeError struct {
+	size           int
+	compressedSize int
+	limit          int
+}
+
+func (e *secretTooLargeError) Error() string {
+	return fmt.Sprintf("merged configuration of %d bytes exceeds the Secret size limit of %d bytes, compressed it still has %d bytes",
+		e.size, e.limit, e.compressedSize)
+}
+
+func (e *secretTooLargeError) reason() string {
+	return "SecretSizeExceeded"
+}
+
+// configSizeLimit returns the number of bytes available for the merged configuration in the Secret storing it.
+// The extra data of the TargetSecret count against the limit, unless the TargetSecret contains a pointer config.
+func configSizeLimit(ign *metalv1alpha1.IgnitionV3) int {
+	if ign.Spec.Pointer != nil {
+		return maxSecretDataSize
+	}
+	return dataSizeLimit(ign.Spec.TargetSecret.ExtraData)
+}
+
+// dataSizeLimit returns the number of bytes left for the merged configuration in an object storing extraData along with it.
+func dataSizeLimit(extraData map[string]string) int {
+	limit := maxSecretDataSize
+	for _, value := range extraData {
+		limit -= len(value)
+	}
+	return limit
+}
+
+// fitSecretSize returns configBytes unchanged when they don't exceed limit. Larger configs are gzipped into a data URL
+// which replaces a wrapper config rendered in the given ignition specification version. The strategy used is returned
+// along with the bytes to store.
+func fitSecretSize(configBytes []byte, version string, limit int) ([]byte, string, error) {
+	if len(configBytes) <= limit {
+		return configBytes, metalv1alpha1.OutputStrategyPlain, nil
+	}
+
+	resource, err := encodeFileContents(configBytes, ptr.To("gzip"))
+	if err != nil {
+		return nil, "", fmt.Errorf("couldn't compress merged configuration. Reason: %v", err)
+	}
+	wrapper := ignitiontypes.Config{}
+	wrapper.Ignition.Version = ignitiontypes.MaxVersion.String()
+	wrapper.Ignition.Config.Replace = ignitiontypes.Resource{
+		Source:       resource.Source,
+		Compression:  resource.Compression,
+		Verification: ignitiontypes.Verification{Hash: resource.Verification.Hash},
+	}
+	wrapperBytes, err := render(wrapper, version)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(wrapperBytes) > limit {
+		return nil, "", &secretTooLargeError{size: len(configBytes), compressedSize: len(wrapperBytes), limit: limit}
+	}
+	return wrapperBytes, metalv1alpha1.OutputStrategyCompressed, nil
+}
@@ -41,6 +41,7 @@ const (
 	eventuallyTimeout    = 3 * time.Second
 	consistentlyDuration = 1 * time.Second
 	namespace            = "test-namespace"
+	sourceNamespace      = "test-source-namespace"
 )
 
 func TestControllers(t *testing.T) {
@@ -88,6 +89,10 @@ var _ = BeforeSuite(func() {
 	Expect(k8sClient).NotTo(BeNil())
 
 	Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})).To(Succeed())
+	Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   sourceNamespace,
+		Labels: map[string]string{"ignition-source": "true"},
+	}})).To(Succeed())
 })
 
 var _ = AfterSuite(func() {
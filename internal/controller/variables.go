@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	ignitiontypes "github.com/coreos/ignition/v2/config/v3_5/types"
+	"github.com/vincent-petithory/dataurl"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
+)
+
+// variablePattern matches ${name} placeholders and their escaped form $${name}.
+var variablePattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// undefinedVariablesError is returned when a merged config uses placeholders of variables which aren't defined.
+type undefinedVariablesError struct {
+	names []string
+}
+
+func (e *undefinedVariablesError) Error() string {
+	return fmt.Sprintf("merged configuration uses undefined variables: %s", strings.Join(e.names, ", "))
+}
+
+func (e *undefinedVariablesError) reason() string {
+	return "VariablesUndefined"
+}
+
+// resolveVariables returns the variables of a target ignition, or nil when it doesn't define any.
+// ConfigMaps of variablesFrom which don't exist are recorded in state.
+func (r *IgnitionV3Reconciler) resolveVariables(ctx context.Context, ign *metalv1alpha1.IgnitionV3, state *mergeState) (map[string]string, error) {
+	if ign.Spec.Variables == nil && ign.Spec.VariablesFrom == nil {
+		return nil, nil
+	}
+
+	variables := map[string]string{}
+	for _, ref := range ign.Spec.VariablesFrom {
+		configMap := &corev1.ConfigMap{}
+		nn := types.NamespacedName{Namespace: ign.Namespace, Name: ref.Name}
+		if err := r.Get(ctx, nn, configMap); apierrors.IsNotFound(err) {
+			state.missingSources = append(state.missingSources, fmt.Sprintf("%s %s", metalv1alpha1.ConfigMapKind, nn.String()))
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("couldn't get %s. Reason: %v", metalv1alpha1.ConfigMapKind, err)
+		}
+		maps.Copy(variables, configMap.Data)
+	}
+	maps.Copy(variables, ign.Spec.Variables)
+	return variables, nil
+}
+
+// substituteVariables replaces the placeholders in the data URL contents of files, the contents of systemd units
+// and their dropins and the kernel arguments of config. Escaped placeholders are always unescaped, the other ones
+// are kept unchanged when variables is nil.
+func substituteVariables(config ignitiontypes.Config, variables map[string]string) (ignitiontypes.Config, error) {
+	undefined := map[string]struct{}{}
+	substitute := func(value string) string {
+		return variablePattern.ReplaceAllStringFunc(value, func(placeholder string) string {
+			if strings.HasPrefix(placeholder, "$$") {
+				return placeholder[1:]
+			}
+			if variables == nil {
+				return placeholder
+			}
+			name := variablePattern.FindStringSubmatch(placeholder)[1]
+			variable, ok := variables[name]
+			if !ok {
+				undefined[name] = struct{}{}
+				return placeholder
+			}
+			return variable
+		})
+	}
+
+	files := make([]ignitiontypes.File, len(config.Storage.Files))
+	for i, file := range config.Storage.Files {
+		var err error
+		if file.Contents, err = substituteResource(file.Contents, substitute); err != nil {
+			return ignitiontypes.Config{}, fmt.Errorf("couldn't substitute variables in %s. Reason: %v", file.Path, err)
+		}
+		appends := make([]ignitiontypes.Resource, len(file.Append))
+		for j, resource := range file.Append {
+			if appends[j], err = substituteResource(resource, substitute); err != nil {
+				return ignitiontypes.Config{}, fmt.Errorf("couldn't substitute variables in %s. Reason: %v", file.Path, err)
+			}
+		}
+		file.Append = appends
+		files[i] = file
+	}
+	config.Storage.Files = files
+
+	units := make([]ignitiontypes.Unit, len(config.Systemd.Units))
+	for i, unit := range config.Systemd.Units {
+		if unit.Contents != nil {
+			unit.Contents = ptr.To(substitute(*unit.Contents))
+		}
+		dropins := make([]ignitiontypes.Dropin, len(unit.Dropins))
+		for j, dropin := range unit.Dropins {
+			if dropin.Contents != nil {
+				dropin.Contents = ptr.To(substitute(*dropin.Contents))
+			}
+			dropins[j] = dropin
+		}
+		unit.Dropins = dropins
+		units[i] = unit
+	}
+	config.Systemd.Units = units
+
+	substituteArgs := func(args []ignitiontypes.KernelArgument) []ignitiontypes.KernelArgument {
+		result := make([]ignitiontypes.KernelArgument, len(args))
+		for i, arg := range args {
+			result[i] = ignitiontypes.KernelArgument(substitute(string(arg)))
+		}
+		return result
+	}
+	config.KernelArguments.ShouldExist = substituteArgs(config.KernelArguments.ShouldExist)
+	config.KernelArguments.ShouldNotExist = substituteArgs(config.KernelArguments.ShouldNotExist)
+
+	if len(undefined) > 0 {
+		return ignitiontypes.Config{}, &undefinedVariablesError{names: slices.Sorted(maps.Keys(undefined))}
+	}
+	return config, nil
+}
+
+// substituteResource replaces the placeholders in the content of a data URL resource. The resource is encoded again
+// with a new verification hash when its content changed. Resources fetched from other URLs are returned unchanged.
+func substituteResource(resource ignitiontypes.Resource, substitute func(string) string) (ignitiontypes.Resource, error) {
+	if resource.Source == nil || !strings.HasPrefix(*resource.Source, "data:") {
+		return resource, nil
+	}
+	url, err := dataurl.DecodeString(*resource.Source)
+	if err != nil {
+		return ignitiontypes.Resource{}, fmt.Errorf("couldn't decode data URL. Reason: %v", err)
+	}
+
+	content := url.Data
+	if resource.Compression != nil && *resource.Compression == "gzip" {
+		reader, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return ignitiontypes.Resource{}, fmt.Errorf("couldn't decompress data URL. Reason: %v", err)
+		}
+		if content, err = io.ReadAll(reader); err != nil {
+			return ignitiontypes.Resource{}, fmt.Errorf("couldn't decompress data URL. Reason: %v", err)
+		}
+	}
+
+	substituted := substitute(string(content))
+	if substituted == string(content) {
+		return resource, nil
+	}
+	encoded, err := encodeFileContents([]byte(substituted), resource.Compression)
+	if err != nil {
+		return ignitiontypes.Resource{}, err
+	}
+	resource.Source = encoded.Source
+	resource.Verification.Hash = encoded.Verification.Hash
+	return resource, nil
+}
@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"cmp"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
+)
+
+// IgnitionContentType is the media type of ignition configs.
+const IgnitionContentType = "application/vnd.coreos.ignition+json"
+
+// IgnitionPath is the path the merged configuration of a target IgnitionV3 is served at.
+const IgnitionPath = "/ignition/{namespace}/{name}"
+
+// tokenQueryParameter carries the bearer token for clients which can't set headers, e.g. ignition.config.url.
+const tokenQueryParameter = "token"
+
+const shutdownTimeout = 10 * time.Second
+
+// IgnitionServer serves the merged configurations of target IgnitionV3 objects to machines.
+// A configuration is only served to requests presenting the token of the target's tokenFrom.
+type IgnitionServer struct {
+	Client      client.Reader
+	BindAddress string
+}
+
+// Start runs the server until ctx is done.
+func (s *IgnitionServer) Start(ctx context.Context) error {
+	log := ctrllog.FromContext(ctx).WithName("ignition-server")
+
+	server := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(_ net.Listener) context.Context { return ctx },
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Info("Serving ignition configs", "address", s.BindAddress)
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("ignition server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("couldn't shut down ignition server: %w", err)
+	}
+	return nil
+}
+
+// NeedLeaderElection returns false, every replica of the manager serves configurations.
+func (s *IgnitionServer) NeedLeaderElection() bool {
+	return false
+}
+
+// Handler returns the HTTP handler of the server.
+func (s *IgnitionServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+IgnitionPath, s.serveIgnition)
+	mux.HandleFunc("GET "+MachineIgnitionPath, s.serveMachineIgnition)
+	return mux
+}
+
+func (s *IgnitionServer) serveIgnition(w http.ResponseWriter, req *http.Request) {
+	nn := types.NamespacedName{Namespace: req.PathValue("namespace"), Name: req.PathValue("name")}
+	s.writeConfig(w, req, nn)
+}
+
+func (s *IgnitionServer) serveMachineIgnition(w http.ResponseWriter, req *http.Request) {
+	nn, err := s.matchMachineBinding(req.Context(), req.PathValue("namespace"), machineFromQuery(req.URL.Query()))
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+	s.writeConfig(w, req, nn)
+}
+
+// writeConfig writes the merged configuration of the target IgnitionV3 nn if the request presents its token.
+func (s *IgnitionServer) writeConfig(w http.ResponseWriter, req *http.Request, nn types.NamespacedName) {
+	config, err := s.getConfig(req.Context(), nn, requestToken(req))
+	if err != nil {
+		writeError(w, req, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", IgnitionContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write(config); err != nil {
+		ctrllog.FromContext(req.Context()).WithName("ignition-server").Error(err, "couldn't write ignition", metalv1alpha1.IgnitionV3Kind, nn.String())
+	}
+}
+
+// writeError writes the status of a statusError, other errors are logged and reported as internal server errors.
+func writeError(w http.ResponseWriter, req *http.Request, err error) {
+	var statusErr *statusError
+	if !errors.As(err, &statusErr) {
+		ctrllog.FromContext(req.Context()).WithName("ignition-server").Error(err, "couldn't serve ignition", "path", req.URL.Path)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if statusErr.code == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="khalkeon"`)
+	}
+	http.Error(w, http.StatusText(statusErr.code), statusErr.code)
+}
+
+// statusError is returned when a request can't be served for a reason the client is told about.
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return http.StatusText(e.code)
+}
+
+// getConfig returns the merged configuration of a target IgnitionV3 after verifying token.
+// Ignitions which aren't served and missing configurations are reported as not found,
+// so clients can't tell them apart.
+func (s *IgnitionServer) getConfig(ctx context.Context, nn types.NamespacedName, token string) ([]byte, error) {
+	ign := &metalv1alpha1.IgnitionV3{}
+	if err := s.Client.Get(ctx, nn, ign); apierrors.IsNotFound(err) {
+		return nil, &statusError{code: http.StatusNotFound}
+	} else if err != nil {
+		return nil, fmt.Errorf("couldn't get %s. Reason: %v", metalv1alpha1.IgnitionV3Kind, err)
+	}
+	if ign.Spec.TargetSecret == nil || ign.Spec.TokenFrom == nil {
+		return nil, &statusError{code: http.StatusNotFound}
+	}
+
+	expected, err := s.getSecretKey(ctx, ign.Namespace, ign.Spec.TokenFrom.Name, ign.Spec.TokenFrom.Key)
+	if err != nil {
+		return nil, err
+	}
+	expected = []byte(strings.TrimSpace(string(expected)))
+	if len(expected) == 0 || subtle.ConstantTimeCompare(expected, []byte(token)) != 1 {
+		return nil, &statusError{code: http.StatusUnauthorized}
+	}
+
+	// the TargetSecret of an ignition using a pointer config contains the pointer, not the merged configuration
+	secretName, key := ign.Spec.TargetSecret.Name, cmp.Or(ign.Spec.TargetSecret.Key, metalv1alpha1.TargetSecretConfigKey)
+	if ign.Spec.Pointer != nil {
+		secretName, key = ign.Name+metalv1alpha1.RenderedSecretSuffix, metalv1alpha1.TargetSecretConfigKey
+	}
+	config, err := s.getSecretKey(ctx, ign.Namespace, secretName, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(config) == 0 {
+		return nil, &statusError{code: http.StatusNotFound}
+	}
+	return config, nil
+}
+
+// getSecretKey returns the value of a key of a Secret, or nil when the Secret or the key doesn't exist.
+func (s *IgnitionServer) getSecretKey(ctx context.Context, namespace, name, key string) ([]byte, error) {
+	secret := &corev1.Secret{}
+	if err := s.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret); apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("couldn't get %s. Reason: %v", metalv1alpha1.SecretKind, err)
+	}
+	return secret.Data[key], nil
+}
+
+// requestToken returns the bearer token of the Authorization header, falling back to the token query parameter.
+func requestToken(req *http.Request) string {
+	if token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return req.URL.Query().Get(tokenQueryParameter)
+}
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
+)
+
+var _ = Describe("IgnitionServer", func() {
+	const (
+		namespace = "test-namespace"
+		name      = "test-ignition"
+		token     = "test-token"
+		config    = `{"ignition":{"version":"3.5.0"}}`
+		path      = "/ignition/" + namespace + "/" + name
+	)
+
+	var (
+		ignition    *metalv1alpha1.IgnitionV3
+		tokenSecret *corev1.Secret
+		target      *corev1.Secret
+
+		serve = func(objects []client.Object, req *http.Request) *httptest.ResponseRecorder {
+			recorder := httptest.NewRecorder()
+			newServer(objects...).Handler().ServeHTTP(recorder, req)
+			return recorder
+		}
+		bearerRequest = func(token string) *http.Request {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			return req
+		}
+	)
+
+	BeforeEach(func() {
+		ignition = &metalv1alpha1.IgnitionV3{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		ignition.Spec.TargetSecret = &metalv1alpha1.TargetSecret{Name: "test-target"}
+		ignition.Spec.TokenFrom = &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "test-token"}, Key: "token"}
+		tokenSecret = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "test-token", Namespace: namespace}, Data: map[string][]byte{"token": []byte(token + "\n")}}
+		target = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "test-target", Namespace: namespace}, Data: map[string][]byte{metalv1alpha1.TargetSecretConfigKey: []byte(config)}}
+	})
+
+	It("should serve the config of the target with the bearer token", func() {
+		recorder := serve([]client.Object{ignition, tokenSecret, target}, bearerRequest(token))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Type")).To(Equal(IgnitionContentType))
+		Expect(recorder.Body.String()).To(Equal(config))
+	})
+
+	It("should serve the config of the target with the token query parameter", func() {
+		recorder := serve([]client.Object{ignition, tokenSecret, target}, httptest.NewRequest(http.MethodGet, path+"?token="+token, nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(Equal(config))
+	})
+
+	It("when the ignition uses a pointer config, should serve the config of the rendered secret", func() {
+		ignition.Spec.Pointer = &metalv1alpha1.IgnitionV3Pointer{URL: "http://khalkeon.example.com:8080"}
+		target.Data[metalv1alpha1.TargetSecretConfigKey] = []byte("pointer")
+		rendered := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name + metalv1alpha1.RenderedSecretSuffix, Namespace: namespace}, Data: map[string][]byte{metalv1alpha1.TargetSecretConfigKey: []byte(config)}}
+		recorder := serve([]client.Object{ignition, tokenSecret, target, rendered}, bearerRequest(token))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(Equal(config))
+	})
+
+	It("when the token is wrong, should respond unauthorized", func() {
+		recorder := serve([]client.Object{ignition, tokenSecret, target}, bearerRequest("wrong"))
+		Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+		Expect(recorder.Header().Get("WWW-Authenticate")).To(HavePrefix("Bearer"))
+	})
+
+	It("when the token secret doesn't exist, should respond unauthorized", func() {
+		recorder := serve([]client.Object{ignition, target}, bearerRequest(""))
+		Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("when the ignition has no tokenFrom, should respond not found", func() {
+		ignition.Spec.TokenFrom = nil
+		recorder := serve([]client.Object{ignition, tokenSecret, target}, bearerRequest(token))
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("when the target secret doesn't exist yet, should respond not found", func() {
+		recorder := serve([]client.Object{ignition, tokenSecret}, bearerRequest(token))
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("when the ignition doesn't exist, should respond not found", func() {
+		recorder := serve([]client.Object{tokenSecret, target}, bearerRequest(token))
+		Expect(recorder.Code).To(Equal(http.StatusNotFound))
+	})
+})
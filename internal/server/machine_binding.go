@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+
+	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
+)
+
+// MachineIgnitionPath is the path the merged configuration of the target IgnitionV3 bound to a machine is served at.
+// The machine is identified by the mac, uuid and serial query parameters and only matched against the bindings
+// of the namespace.
+const MachineIgnitionPath = "/ignition/{namespace}"
+
+// +kubebuilder:rbac:groups=metal.cobaltcore.dev,resources=machinebindings,verbs=get;list;watch
+
+// machine holds the attributes a machine sends to identify itself.
+type machine struct {
+	mac    string
+	uuid   string
+	serial string
+}
+
+// machineFromQuery reads the attributes of a machine from the query parameters of a request.
+func machineFromQuery(query url.Values) machine {
+	return machine{
+		mac:    normalizeMAC(query.Get("mac")),
+		uuid:   strings.ToLower(query.Get("uuid")),
+		serial: query.Get("serial"),
+	}
+}
+
+// normalizeMAC returns a MAC address in lower case with colons as separators.
+func normalizeMAC(mac string) string {
+	return strings.ToLower(strings.ReplaceAll(mac, "-", ":"))
+}
+
+// matches returns whether all attributes set in selector match the machine and how many attributes are set.
+func (m machine) matches(selector metalv1alpha1.MachineSelector) (bool, int) {
+	specificity := 0
+	for _, attribute := range []struct{ selected, actual string }{
+		{normalizeMAC(selector.MAC), m.mac},
+		{strings.ToLower(selector.UUID), m.uuid},
+		{selector.Serial, m.serial},
+	} {
+		if attribute.selected == "" {
+			continue
+		}
+		if attribute.selected != attribute.actual {
+			return false, 0
+		}
+		specificity++
+	}
+	return specificity > 0, specificity
+}
+
+// matchMachineBinding returns the target IgnitionV3 of the MachineBinding of namespace matching the machine with
+// the most attributes. Machines matching no binding, or several bindings with the same number of attributes,
+// aren't served.
+func (s *IgnitionServer) matchMachineBinding(ctx context.Context, namespace string, m machine) (types.NamespacedName, error) {
+	if m == (machine{}) {
+		return types.NamespacedName{}, &statusError{code: http.StatusBadRequest}
+	}
+
+	bindingList := &metalv1alpha1.MachineBindingList{}
+	if err := s.Client.List(ctx, bindingList, client.InNamespace(namespace)); err != nil {
+		return types.NamespacedName{}, fmt.Errorf("couldn't list %s. Reason: %v", metalv1alpha1.MachineBindingKind, err)
+	}
+
+	var matched []metalv1alpha1.MachineBinding
+	best := 0
+	for _, binding := range bindingList.Items {
+		ok, specificity := m.matches(binding.Spec.Selector)
+		switch {
+		case !ok || specificity < best:
+			continue
+		case specificity > best:
+			matched = nil
+			best = specificity
+		}
+		matched = append(matched, binding)
+	}
+
+	switch len(matched) {
+	case 0:
+		return types.NamespacedName{}, &statusError{code: http.StatusNotFound}
+	case 1:
+		return types.NamespacedName{Namespace: matched[0].Namespace, Name: matched[0].Spec.Target.Name}, nil
+	default:
+		names := make([]string, len(matched))
+		for i, binding := range matched {
+			names[i] = types.NamespacedName{Namespace: binding.Namespace, Name: binding.Name}.String()
+		}
+		ctrllog.FromContext(ctx).WithName("ignition-server").Info("Machine matches several bindings", metalv1alpha1.MachineBindingKind, names)
+		return types.NamespacedName{}, &statusError{code: http.StatusConflict}
+	}
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2025 SAP SE or an SAP affiliate company and IronCore contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metalv1alpha1 "github.com/cobaltcore-dev/khalkeon/api/v1alpha1"
+)
+
+var _ = Describe("MachineBinding", func() {
+	const (
+		namespace = "test-namespace"
+		token     = "test-token"
+	)
+
+	var (
+		objects []client.Object
+
+		newTarget = func(name string) []client.Object {
+			ignition := &metalv1alpha1.IgnitionV3{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+			ignition.Spec.TargetSecret = &metalv1alpha1.TargetSecret{Name: name}
+			ignition.Spec.TokenFrom = &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "test-token"}, Key: "token"}
+			target := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}, Data: map[string][]byte{metalv1alpha1.TargetSecretConfigKey: []byte(name)}}
+			return []client.Object{ignition, target}
+		}
+		newBinding = func(name, target string, selector metalv1alpha1.MachineSelector) *metalv1alpha1.MachineBinding {
+			binding := &metalv1alpha1.MachineBinding{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+			binding.Spec.Selector = selector
+			binding.Spec.Target.Name = target
+			return binding
+		}
+		serve = func(query string) *httptest.ResponseRecorder {
+			recorder := httptest.NewRecorder()
+			newServer(objects...).Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/ignition/"+namespace+"?"+query+"&token="+token, nil))
+			return recorder
+		}
+	)
+
+	BeforeEach(func() {
+		objects = []client.Object{&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "test-token", Namespace: namespace}, Data: map[string][]byte{"token": []byte(token)}}}
+		objects = append(objects, newTarget("worker")...)
+		objects = append(objects, newTarget("special")...)
+		objects = append(objects,
+			newBinding("by-mac", "worker", metalv1alpha1.MachineSelector{MAC: "52:54:00:12:34:56"}),
+			newBinding("by-mac-and-serial", "special", metalv1alpha1.MachineSelector{MAC: "52:54:00:12:34:56", Serial: "S1"}),
+			newBinding("by-uuid", "worker", metalv1alpha1.MachineSelector{UUID: "8A6B1C2D-0000-4000-8000-000000000001"}),
+		)
+	})
+
+	It("should serve the config of the target bound to the machine", func() {
+		recorder := serve("mac=52-54-00-12-34-56")
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Type")).To(Equal(IgnitionContentType))
+		Expect(recorder.Body.String()).To(Equal("worker"))
+
+		recorder = serve("uuid=8a6b1c2d-0000-4000-8000-000000000001")
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(Equal("worker"))
+	})
+
+	It("when several bindings match, should serve the config of the most specific one", func() {
+		recorder := serve("mac=52:54:00:12:34:56&serial=S1")
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(Equal("special"))
+	})
+
+	It("when several bindings are equally specific, should respond conflict", func() {
+		objects = append(objects, newBinding("by-serial", "worker", metalv1alpha1.MachineSelector{Serial: "S2"}), newBinding("by-serial-2", "special", metalv1alpha1.MachineSelector{Serial: "S2"}))
+		Expect(serve("serial=S2").Code).To(Equal(http.StatusConflict))
+	})
+
+	It("when a binding of another namespace is more specific, should ignore it", func() {
+		binding := newBinding("other", "special", metalv1alpha1.MachineSelector{MAC: "52:54:00:12:34:56", Serial: "S9"})
+		binding.Namespace = "other-namespace"
+		objects = append(objects, binding)
+
+		recorder := serve("mac=52:54:00:12:34:56&serial=S9")
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(Equal("worker"))
+	})
+
+	It("when no binding matches, should respond not found", func() {
+		Expect(serve("mac=52:54:00:12:34:57").Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("when the machine sends no attributes, should respond bad request", func() {
+		Expect(serve("").Code).To(Equal(http.StatusBadRequest))
+	})
+})